@@ -0,0 +1,184 @@
+// Package clitest helps to test cli commands.
+package clitest
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nikand.dev/go/cli"
+)
+
+// Examples checks the examples of the command and all its subcommands.
+// Each example is a subtest named after its command line.
+// Examples are run against the root command. See Example.
+func Examples(t *testing.T, c *cli.Command) {
+	t.Helper()
+
+	root := c
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	var walk func(cur *cli.Command)
+	walk = func(cur *cli.Command) {
+		for _, e := range cur.Examples {
+			e := e
+
+			t.Run(e.Command, func(t *testing.T) {
+				Example(t, root, e)
+			})
+		}
+
+		for _, sub := range cur.Commands {
+			if sub != nil {
+				walk(sub)
+			}
+		}
+	}
+
+	walk(c)
+}
+
+// Example checks the example against the root command.
+// Examples without Output are only parsed, no actions are run.
+// Examples with Output are run and their output is compared to Output ignoring final newlines.
+// Stdout and Stderr are captured together, Stdin is empty.
+// The command tree state is restored afterwards, see Snapshot.
+func Example(t *testing.T, root *cli.Command, e cli.Example) {
+	t.Helper()
+
+	args, err := e.Args()
+	if err != nil {
+		t.Fatalf("split command line: %v", err)
+	}
+
+	defer Snapshot(root)()
+
+	var out bytes.Buffer
+
+	walkCommands(root, func(c *cli.Command) {
+		c.Stdin = strings.NewReader("")
+		c.Stdout = &out
+		c.Stderr = &out
+	})
+
+	if e.Output == "" {
+		_, err = cli.Parse(root, args, nil)
+		if errors.Is(err, cli.ErrExit) {
+			err = nil
+		}
+	} else {
+		err = cli.Run(root, args, nil)
+	}
+
+	if err != nil {
+		t.Fatalf("%v\noutput:\n%s", err, out.Bytes())
+	}
+
+	if e.Output == "" {
+		return
+	}
+
+	exp := strings.TrimRight(e.Output, "\n")
+	got := strings.TrimRight(out.String(), "\n")
+
+	if got != exp {
+		t.Errorf("output mismatch\nexpected:\n%s\ngot:\n%s", exp, got)
+	}
+}
+
+// DocsUpToDate checks the docs in the dir are the same as g generates for the command.
+// Missing, outdated and extra files of the g format are reported.
+func DocsUpToDate(t *testing.T, c *cli.Command, g *cli.Docs, dir string) {
+	t.Helper()
+
+	defer Snapshot(c)()
+
+	tmp := t.TempDir()
+
+	files, err := g.WriteDir(tmp, c)
+	if err != nil {
+		t.Fatalf("generate docs: %v", err)
+	}
+
+	gen := map[string]bool{}
+
+	for _, f := range files {
+		name := filepath.Base(f)
+		gen[name] = true
+
+		exp, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("read generated: %v", err)
+		}
+
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			t.Errorf("docs: %v: missing", name)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("read docs: %v", err)
+		}
+
+		if !bytes.Equal(got, exp) {
+			t.Errorf("docs: %v: out of date", name)
+		}
+	}
+
+	ext := filepath.Ext(files[0])
+
+	old, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil {
+		t.Fatalf("list docs: %v", err)
+	}
+
+	for _, f := range old {
+		if name := filepath.Base(f); !gen[name] {
+			t.Errorf("docs: %v: no such command", name)
+		}
+	}
+}
+
+// Snapshot saves all the fields of all the commands and flags in the tree.
+// The returned func restores them.
+// It makes global built-ins like cli.HelpFlag reusable between runs.
+// Values modified in place, like flag.Setter implementations, are not restored.
+func Snapshot(root *cli.Command) (restore func()) {
+	cmds := map[*cli.Command]cli.Command{}
+	flags := map[*cli.Flag]cli.Flag{}
+
+	walkCommands(root, func(c *cli.Command) {
+		cmds[c] = *c
+
+		for _, f := range c.Flags {
+			if f != nil {
+				flags[f] = *f
+			}
+		}
+	})
+
+	return func() {
+		for c, s := range cmds {
+			*c = s
+		}
+
+		for f, s := range flags {
+			*f = s
+		}
+	}
+}
+
+func walkCommands(c *cli.Command, visit func(c *cli.Command)) {
+	visit(c)
+
+	for _, sub := range c.Commands {
+		if sub != nil {
+			walkCommands(sub, visit)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package clitest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/nikandfor/assert"
+
+	"nikand.dev/go/cli"
+	"nikand.dev/go/cli/flag"
+)
+
+func TestExamples(t *testing.T) {
+	var deployed []string
+
+	app := &cli.Command{
+		Name: "app",
+		Flags: []*cli.Flag{
+			flag.New("verbose,v", false, "verbose output"),
+			cli.HelpFlag,
+		},
+		Examples: []cli.Example{{
+			Command: "app --help",
+		}},
+		Commands: []*cli.Command{{
+			Name: "deploy",
+			Args: cli.Args{},
+			Action: func(c *cli.Command) error {
+				deployed = append(deployed, c.Args...)
+
+				fmt.Fprintf(c.Stdout, "deployed %v to %v\n", c.Args, c.String("to"))
+
+				return nil
+			},
+			Flags: []*cli.Flag{
+				flag.New("to", "", "environment", flag.Required),
+			},
+			Examples: []cli.Example{{
+				Command:     "app deploy --to prod v1",
+				Description: "parsed only",
+			}, {
+				Command: "app -v deploy --to 'staging env' v2",
+				Output:  "deployed v2 to staging env\n",
+			}},
+		}},
+	}
+
+	Examples(t, app)
+
+	assert.Equal(t, []string{"v2"}, deployed)
+
+	assert.Equal(t, false, app.Bool("verbose"))
+	assert.Equal(t, "", app.Commands[0].String("to"))
+	assert.Equal(t, cli.Args{}, app.Commands[0].Args)
+	assert.True(t, app.Stdout == nil)
+}
+
+func TestDocsUpToDate(t *testing.T) {
+	app := &cli.Command{
+		Name:        "app",
+		Description: "test app",
+		Commands: []*cli.Command{{
+			Name:        "sub",
+			Description: "subcommand",
+		}},
+	}
+
+	g := &cli.Docs{}
+	dir := t.TempDir()
+
+	_, err := g.WriteDir(dir, app)
+	assert.NoError(t, err)
+
+	DocsUpToDate(t, app, g, dir)
+}
@@ -0,0 +1,169 @@
+package clitest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"nikand.dev/go/cli"
+	"nikand.dev/go/cli/complete"
+)
+
+type (
+	// Completion is the completion result as a shell script gets it.
+	Completion struct {
+		Candidates []Candidate
+		Directive  complete.Directive
+	}
+
+	Candidate struct {
+		Value       string
+		Description string
+		Group       string
+	}
+)
+
+// Complete runs completion in-process for the command line with the cursor at the byte offset.
+// Negative cursor means the end of the line.
+// The line is split into words like a shell does: quotes and backslash escapes are supported,
+// and the word being completed is cut at the cursor.
+// Words are not split on =, as zsh and fish do, so --flag=<TAB> is passed as one word.
+// The command tree state is restored afterwards, see Snapshot.
+func Complete(root *cli.Command, line string, cursor int) (*Completion, error) {
+	if cursor < 0 || cursor > len(line) {
+		cursor = len(line)
+	}
+
+	words, idx := completeWords(line, cursor)
+
+	env := []string{
+		"CLI_COMP_LINE=" + line,
+		fmt.Sprintf("CLI_COMP_INDEX=%d", cursor),
+		fmt.Sprintf("CLI_COMP_WORDS_LENGTH=%d", len(words)),
+		fmt.Sprintf("CLI_COMP_WORDS_INDEX=%d", idx),
+		"CLI_COMP_BASE=" + words[0],
+		"CLI_COMP_CUR=" + words[idx],
+	}
+
+	if idx > 0 {
+		env = append(env, "CLI_COMP_PREV="+words[idx-1])
+	}
+
+	for i, w := range words {
+		env = append(env, fmt.Sprintf("CLI_COMP_WORDS_%d=%s", i, w))
+	}
+
+	defer Snapshot(root)()
+
+	var out bytes.Buffer
+
+	walkCommands(root, func(c *cli.Command) {
+		c.Stdin = strings.NewReader("")
+		c.Stdout = &out
+		c.Stderr = &out
+	})
+
+	err := cli.Run(root, nil, env)
+	if err != nil {
+		return nil, err
+	}
+
+	cands, d, err := complete.Parse(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("parse output: %w\n%s", err, out.Bytes())
+	}
+
+	res := &Completion{Directive: d}
+
+	for _, c := range cands {
+		v, desc, group := complete.SplitCandidate(c)
+
+		res.Candidates = append(res.Candidates, Candidate{
+			Value:       v,
+			Description: desc,
+			Group:       group,
+		})
+	}
+
+	return res, nil
+}
+
+// Values returns candidate values.
+func (c *Completion) Values() (r []string) {
+	for _, x := range c.Candidates {
+		r = append(r, x.Value)
+	}
+
+	return r
+}
+
+// completeWords splits the line into words and finds the word at the cursor.
+// The word at the cursor is cut at it. An empty word is added if the cursor is not in a word.
+func completeWords(line string, cursor int) (words []string, idx int) {
+	words, open := splitLine(line[:cursor])
+	if !open {
+		words = append(words, "")
+	}
+
+	idx = len(words) - 1
+
+	after, _ := splitLine(line[cursor:])
+
+	if cursor < len(line) && !isSpace(line[cursor]) && len(after) != 0 {
+		after = after[1:] // the rest of the current word
+	}
+
+	return append(words, after...), idx
+}
+
+// splitLine splits the line into words like a shell does.
+// open is true if the last word is not followed by a space,
+// including an unterminated quote.
+func splitLine(line string) (words []string, open bool) {
+	var w []byte
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case quote == '\'' && c == '\'':
+			quote = 0
+		case quote == '\'':
+			w = append(w, c)
+		case c == '\\' && i+1 < len(line) && (quote == 0 || strings.IndexByte("\"\\$`", line[i+1]) != -1):
+			i++
+			w = append(w, line[i])
+		case quote == '"' && c == '"':
+			quote = 0
+		case quote == '"':
+			w = append(w, c)
+		case c == '\'' || c == '"':
+			quote = c
+			open = true
+		case isSpace(c):
+			if open {
+				words = append(words, string(w))
+				w = w[:0]
+				open = false
+			}
+		default:
+			w = append(w, c)
+			open = true
+		}
+
+		if quote != 0 || c == '\\' {
+			open = true
+		}
+	}
+
+	if open {
+		words = append(words, string(w))
+	}
+
+	return words, open
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
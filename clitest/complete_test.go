@@ -0,0 +1,138 @@
+package clitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikandfor/assert"
+
+	"nikand.dev/go/cli"
+	"nikand.dev/go/cli/complete"
+	"nikand.dev/go/cli/flag"
+)
+
+func completeTestCommand() *cli.Command {
+	return &cli.Command{
+		Name: "app",
+		Flags: []*cli.Flag{
+			flag.New("verbose,v", false, "verbose output"),
+			cli.HelpFlag,
+		},
+		Commands: []*cli.Command{{
+			Name:        "deploy",
+			Description: "deploy the app",
+			Args:        cli.Args{},
+			Complete:    cli.CompleteList("v1", "v2"),
+			Action:      func(c *cli.Command) error { return nil },
+			Flags: []*cli.Flag{
+				flag.New("format", "", "output format", func(f *flag.Flag) {
+					f.Complete = cli.FlagCompleter(cli.CompleteList("json", "yaml"))
+				}),
+				flag.New("config", "", "config dir", func(f *flag.Flag) {
+					f.Complete = cli.FlagCompleter(cli.CompleteDirs())
+				}),
+			},
+		}, {
+			Name:        "destroy",
+			Description: "destroy the app",
+			Action:      func(c *cli.Command) error { return nil },
+		}, {
+			Name:   "status",
+			Action: func(c *cli.Command) error { return nil },
+		}},
+	}
+}
+
+func TestComplete(t *testing.T) {
+	app := completeTestCommand()
+
+	res, err := Complete(app, "app de", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []Candidate{
+		{Value: "deploy", Description: "deploy the app", Group: "Commands"},
+		{Value: "destroy", Description: "destroy the app", Group: "Commands"},
+	}, res.Candidates)
+	assert.Equal(t, complete.KeepOrder, res.Directive)
+
+	res, err = Complete(app, "app dep status", len("app dep"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"deploy"}, res.Values())
+
+	res, err = Complete(app, "app deploy --form", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--format"}, res.Values())
+	assert.Equal(t, "Flags", res.Candidates[0].Group)
+
+	res, err = Complete(app, "app deploy ", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1", "v2"}, res.Values())
+
+	assert.True(t, app.Stdout == nil)
+	assert.Equal(t, cli.Args(nil), app.Args)
+}
+
+func TestCompleteMidWord(t *testing.T) {
+	app := completeTestCommand()
+
+	res, err := Complete(app, "app deploy --fo=yaml", len("app deploy --fo"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--format"}, res.Values())
+
+	words, idx := completeWords("app deploy v1", len("app dep"))
+	assert.Equal(t, []string{"app", "dep", "v1"}, words)
+	assert.Equal(t, 1, idx)
+
+	words, idx = completeWords("app  deploy", len("app "))
+	assert.Equal(t, []string{"app", "", "deploy"}, words)
+	assert.Equal(t, 1, idx)
+}
+
+func TestCompleteFlagValue(t *testing.T) {
+	app := completeTestCommand()
+
+	res, err := Complete(app, "app deploy --format=", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--format=json", "--format=yaml"}, res.Values())
+
+	res, err = Complete(app, "app deploy --format=y", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--format=yaml"}, res.Values())
+
+	res, err = Complete(app, "app deploy --format ", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"json", "yaml"}, res.Values())
+}
+
+func TestCompleteDashDash(t *testing.T) {
+	app := completeTestCommand()
+
+	res, err := Complete(app, "app deploy -- -", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(res.Candidates))
+
+	res, err = Complete(app, "app deploy -- ", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1", "v2"}, res.Values())
+}
+
+func TestCompleteQuoted(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "my dir"), 0o755))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "other"), 0o755))
+
+	app := completeTestCommand()
+
+	res, err := Complete(app, "app deploy --config '"+dir+"/my d", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dir + "/my dir/"}, res.Values())
+	assert.True(t, res.Directive&complete.NoSpace != 0)
+
+	res, err = Complete(app, `app deploy --config `+dir+`/my\ d`, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dir + "/my dir/"}, res.Values())
+
+	words, _ := splitLine(`a "b \"c\" d" 'e\f' g\ h`)
+	assert.Equal(t, []string{"a", `b "c" d`, `e\f`, "g h"}, words)
+}
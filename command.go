@@ -26,6 +26,8 @@ type (
 
 		Chosen *Command // chosen command
 
+		RCLoaded []string // rc files loaded, see RCFiles
+
 		// User options
 
 		Name        string // comma separated list of aliases
@@ -38,14 +40,27 @@ type (
 		After  Action
 		Action Action
 
-		//	Complete Action
+		// Complete returns positional arg completion candidates starting with prefix.
+		// c is partially parsed. See completers like CompleteFiles.
+		Complete Completer
 
 		Flags    []*Flag
 		Commands []*Command
+		Topics   []*HelpTopic // extra help pages shown by HelpCmd
+		Examples []Example    // shown in help and docs, checked by clitest.Examples
+
+		// Groups is the order of Group sections of subcommands and flags in help.
+		// Groups not listed follow in the order of their first appearance.
+		// Empty name stands for ungrouped items.
+		Groups []string
 
 		// Hide from help.
 		Hidden bool
 
+		// HelpRenderer renders the command help.
+		// DefaultHelpRenderer is used if nil. Inherited by subcommands.
+		HelpRenderer HelpRenderer
+
 		// EnvPrefix used to capture flag values from env vars.
 		// No capturing is done if empty.
 		// Args have precedence over env vars.
@@ -53,13 +68,37 @@ type (
 		// Inherited by subcommands.
 		EnvPrefix string
 
+		// RCFiles are flagfile names to look for in XDG config dirs,
+		// in the home directory, and in the working directory and its parents.
+		// Found files are loaded as if they were passed by FlagfileFlag in front of the args,
+		// so args have precedence over rc files, and rc files have precedence over env vars.
+		// Files from the dirs closer to the working dir have precedence over the farther ones.
+		// Discovery is disabled by NoRCFlag or NoRCEnv.
+		RCFiles []string
+
 		// ParseEnv and ParseFlag override default behaviour.
 		// Both are inherited by subcommands.
 		ParseEnv  func(c *Command, env []string) ([]string, error)
 		ParseFlag func(c *Command, arg string, args []string) ([]string, error)
 
+		// PromptFlag is called for a missing required flag.
+		// DefaultPromptFlag is used if nil. Inherited by subcommands.
+		PromptFlag func(c *Command, f *Flag) error
+
+		// ResponseFiles enables gcc-style @file args expansion.
+		// Each @file arg is replaced by the file content split into args using flagfile quoting rules.
+		// If the content has NUL bytes it's split on them instead (find -print0 output).
+		// @- reads stdin. Args after -- are not expanded.
+		// Expansion is done before parsing. Only the root command option is used.
+		ResponseFiles bool
+
+		Stdin  io.Reader // set to os.Stdin if nil
 		Stdout io.Writer // set to os.Stdout if nil
 		Stderr io.Writer // the same as Stdout
+
+		// builtinFlags makes fresh Flags for built-in commands like HelpCmd.
+		// They are shared between runs, so they get new flags and empty Args on each parse.
+		builtinFlags func() []*Flag
 	}
 
 	Action func(c *Command) error
@@ -73,14 +112,6 @@ var (
 )
 
 func RunAndExit(c *Command, args, env []string) {
-	if false {
-		c.Env = env
-
-		if err := beforeComplete(c); err != nil {
-			panic(err)
-		}
-	}
-
 	err := Run(c, args, env)
 	if err == nil {
 		return
@@ -98,6 +129,17 @@ func Run(app *Command, args, env []string) (err error) {
 		}
 	}()
 
+	app.Env = env
+
+	if _, ok := app.completeIndex(); ok {
+		return runComplete(app, env)
+	}
+
+	args, err = prepareArgs(app, args)
+	if err != nil {
+		return err
+	}
+
 	cmds := make([]*Command, 0, 4)
 
 	cmds, err = parse(app, args, env, cmds)
@@ -105,27 +147,14 @@ func Run(app *Command, args, env []string) (err error) {
 		return wrap(err, "parse command")
 	}
 
-	err = func() error {
-		for _, c := range cmds {
-			for _, f := range c.Flags {
-				if f == nil {
-					continue
-				}
-
-				err = flag.CheckFlag(f)
-				if err != nil {
-					return wrap(err, f.MainName())
-				}
-			}
-		}
-
-		return nil
-	}()
+	err = checkFlags(cmds, true)
 	if err != nil {
 		return wrap(err, "check flags")
 	}
 
 	for _, c := range cmds {
+		c := c
+
 		if f := c.Before; f != nil {
 			if err = f(c); err != nil {
 				return wrap(err, "before %v", c.MainName())
@@ -145,7 +174,7 @@ func Run(app *Command, args, env []string) (err error) {
 	c := cmds[len(cmds)-1]
 
 	if c.Action == nil {
-		_, err = defaultHelp(&Flag{CurrentCommand: c}, "", nil)
+		err = PrintHelp(c, false)
 		if err != nil {
 			return wrap(err, "help")
 		}
@@ -156,6 +185,44 @@ func Run(app *Command, args, env []string) (err error) {
 	return c.Action(c)
 }
 
+// Parse parses args and env and checks flags like Run does, but runs no actions.
+// Missing required flags are not prompted for.
+// The chosen command is returned.
+// ErrExit is returned as is, it means a flag like HelpFlag has done the job.
+func Parse(app *Command, args, env []string) (c *Command, err error) {
+	args, err = prepareArgs(app, args)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds, err := parse(app, args, env, make([]*Command, 0, 4))
+	if err != nil {
+		return nil, wrap(err, "parse command")
+	}
+
+	err = checkFlags(cmds, false)
+	if err != nil {
+		return nil, wrap(err, "check flags")
+	}
+
+	return cmds[len(cmds)-1], nil
+}
+
+// prepareArgs expands response files if enabled.
+// Args are copied anyway, as secret flags are scrubbed from OSArgs.
+func prepareArgs(app *Command, args []string) ([]string, error) {
+	if !app.ResponseFiles {
+		return append([]string{}, args...), nil
+	}
+
+	args, err := expandResponseFiles(app, args)
+	if err != nil {
+		return nil, wrap(err, "expand response files")
+	}
+
+	return args, nil
+}
+
 func parse(c *Command, args, env []string, cmds []*Command) (_ []*Command, err error) {
 	cmds = append(cmds, c)
 
@@ -166,21 +233,49 @@ func parse(c *Command, args, env []string, cmds []*Command) (_ []*Command, err e
 	args = args[1:]
 
 	c.setup()
+	c.prepareFlags()
+
+	c.Env = env // to be available while parsing env
 
 	c.Env, err = c.parseEnv(env)
 	if err != nil {
 		return cmds, wrap(err, "parse env")
 	}
 
+	c.RCLoaded = nil
+
+	if len(c.RCFiles) != 0 && !c.noRC(args) {
+		rc, err := c.loadRCFiles()
+		if err != nil {
+			return cmds, wrap(err, "load rc files")
+		}
+
+		args = append(rc, args...)
+	}
+
 	for len(args) != 0 {
 		arg := args[0]
 
 		if arg != "" && arg[0] == '-' && arg != "-" && arg != "--" {
-			args, err = c.parseFlag(arg, args[1:])
+			f := c.Flag(flagName(arg))
+
+			rest, err := c.parseFlag(arg, args[1:])
+			if f != nil && f.Secret {
+				var secrets []string
+
+				arg = flag.RedactArg(arg)
+				secrets, err = scrubSecret(args, rest, err)
+
+				for _, q := range cmds {
+					redactArgs(q.OSArgs[1:], secrets)
+				}
+			}
 			if err != nil {
 				return cmds, wrap(err, "parse `%v` flag", arg)
 			}
 
+			args = rest
+
 			continue
 		}
 
@@ -211,7 +306,69 @@ func parse(c *Command, args, env []string, cmds []*Command) (_ []*Command, err e
 	return cmds, nil
 }
 
+// checkFlags checks flags of the parsed commands.
+// Missing required flags are prompted for if prompt is true.
+func checkFlags(cmds []*Command, prompt bool) (err error) {
+	for _, c := range cmds {
+		for _, f := range c.Flags {
+			if f == nil {
+				continue
+			}
+
+			err = flag.CheckFlag(f)
+			if prompt && errors.Is(err, flag.ErrRequired) {
+				err = c.promptFlag(f)
+				if err == nil {
+					err = flag.CheckFlag(f)
+				}
+			}
+			if err != nil {
+				return wrap(err, f.MainName())
+			}
+		}
+	}
+
+	return nil
+}
+
+// scrubSecret returns secret flag values used by the flag arg and the error with them redacted.
+// args[0] is the flag arg, and rest is what's left after the flag was parsed.
+func scrubSecret(args, rest []string, err error) (secrets []string, _ error) {
+	if p := strings.IndexAny(args[0], "= "); p != -1 {
+		secrets = append(secrets, args[0][p+1:])
+	} else if len(args) > 1 && (err != nil || len(rest) < len(args)-1) {
+		secrets = append(secrets, args[1])
+	}
+
+	return secrets, flag.RedactError(err, secrets...)
+}
+
+// redactArgs replaces secrets in args with flag.Redacted, either standalone or as a flag value.
+// Args are matched by value, as they may come from rc files or flagfiles in front of them,
+// so an equal non-secret arg is redacted as well.
+func redactArgs(args, secrets []string) {
+	for i, a := range args {
+		for _, s := range secrets {
+			switch {
+			case s == "":
+			case a == s:
+				args[i] = flag.Redacted
+			case strings.HasPrefix(a, "-") && (strings.HasSuffix(a, "="+s) || strings.HasSuffix(a, " "+s)):
+				args[i] = flag.RedactArg(a)
+			}
+		}
+	}
+}
+
 func (c *Command) setup() {
+	if c.Stdin == nil {
+		if c.Parent != nil {
+			c.Stdin = c.Parent.Stdin
+		} else {
+			c.Stdin = os.Stdin
+		}
+	}
+
 	if c.Stdout == nil {
 		if c.Parent != nil {
 			c.Stdout = c.Parent.Stdout
@@ -229,8 +386,37 @@ func (c *Command) setup() {
 	}
 
 	for _, sub := range c.Commands {
-		sub.Parent = c
+		if sub != nil {
+			sub.Parent = c
+		}
+	}
+}
+
+// prepareFlags is called before the command is parsed.
+// Built-in commands get fresh flags and empty Args, and flag defaults are saved for help.
+func (c *Command) prepareFlags() {
+	if c.builtinFlags != nil {
+		c.Flags = c.builtinFlags()
+
+		if c.Args != nil {
+			c.Args = Args{}
+		}
+	}
+
+	for _, f := range c.Flags {
+		if f != nil {
+			f.SaveDefault()
+		}
+	}
+}
+
+// StdinReader returns command stdin or os.Stdin if it's not set.
+func (c *Command) StdinReader() io.Reader {
+	if c.Stdin != nil {
+		return c.Stdin
 	}
+
+	return os.Stdin
 }
 
 func ParseFlag(c *Command, arg string, more []string) ([]string, error) {
@@ -326,12 +512,8 @@ func (c *Command) completeIndex() (int, bool) {
 	return int(i), true
 }
 
-func (c *Command) complete() error {
-	//	if c.Complete != nil {
-	//		return c.Complete(c)
-	//	}
-
-	return DefaultComplete(c)
+func (c *Command) complete(used map[*Flag]bool) error {
+	return defaultComplete(c, used)
 }
 
 func GetEnvPrefix(c *Command) string {
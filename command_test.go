@@ -2,6 +2,9 @@ package cli
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/nikandfor/assert"
@@ -171,3 +174,249 @@ Possible multiline.
 
 	assert.Equal(t, ``, buf.String())
 }
+
+func TestSecretFlag(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name:   "app",
+		Action: func(*Command) error { return nil },
+		Flags: []*Flag{
+			flag.New("password", "default_secret", "", flag.Secret),
+			flag.New("pin", 0, "", flag.Secret),
+			flag.New("verbose", false, ""),
+			HelpFlag,
+		},
+		Stdout: &buf,
+	}
+
+	args := []string{"app", "--password", "hunter2", "--verbose"}
+
+	err := Run(c, args, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", c.Flag("password").Value)
+	assert.Equal(t, []string{"app", "--password", "******", "--verbose"}, c.OSArgs)
+	assert.Equal(t, "hunter2", args[2], "caller args must not be modified")
+
+	err = Run(c, []string{"app", "--password=hunter2", "--pin=12x34"}, nil)
+	assert.Error(t, err)
+	assert.False(t, strings.Contains(err.Error(), "12x34"))
+	assert.True(t, strings.Contains(err.Error(), "--pin=******"))
+	assert.Equal(t, []string{"app", "--password=******", "--pin=******"}, c.OSArgs)
+
+	err = Run(c, []string{"app"}, []string{"APP_PIN=12x34"})
+	assert.NoError(t, err)
+
+	c.EnvPrefix = "APP_"
+
+	err = Run(c, []string{"app"}, []string{"APP_PIN=12x34"})
+	assert.Error(t, err)
+	assert.False(t, strings.Contains(err.Error(), "12x34"))
+
+	err = Run(c, []string{"app", "--help"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(buf.String(), "password"))
+	assert.False(t, strings.Contains(buf.String(), "default_secret"))
+}
+
+func TestSecretFlagFlagfile(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+	defer func(old func() (string, error)) { getwd = old }(getwd)
+
+	readFile = func(n string) ([]byte, error) {
+		switch n {
+		case "ff", "/home/user/.apprc":
+			return []byte("--verbose"), nil
+		}
+
+		return nil, os.ErrNotExist
+	}
+
+	getwd = func() (string, error) { return "/home/user", nil }
+
+	c := &Command{
+		Name:   "app",
+		Action: func(*Command) error { return nil },
+		Flags: []*Flag{
+			flag.New("password", "", "", flag.Secret),
+			flag.New("verbose", false, ""),
+			FlagfileFlag,
+		},
+		Commands: []*Command{{
+			Name:   "sub",
+			Action: func(*Command) error { return nil },
+		}},
+	}
+
+	err := Run(c, []string{"app", "--flagfile=ff", "--password", "hunter2"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", c.Flag("password").Value)
+	assert.Equal(t, []string{"app", "--flagfile=ff", "--password", "******"}, c.OSArgs)
+
+	err = Run(c, []string{"app", "--flagfile", "ff", "sub", "--password=hunter2"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app", "--flagfile", "ff", "sub", "--password=******"}, c.OSArgs)
+	assert.Equal(t, []string{"sub", "--password=******"}, c.Commands[0].OSArgs)
+
+	c.RCFiles = []string{".apprc"}
+
+	err = Run(c, []string{"app", "--password", "hunter2"}, []string{"HOME=/home/user"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/home/user/.apprc"}, c.RCLoaded)
+	assert.Equal(t, []string{"app", "--password", "******"}, c.OSArgs)
+}
+
+func TestSecretFlagRef(t *testing.T) {
+	pin := filepath.Join(t.TempDir(), "pin")
+
+	err := os.WriteFile(pin, []byte("hunter2\n"), 0o600)
+	assert.NoError(t, err)
+
+	c := &Command{
+		Name:   "app",
+		Action: func(*Command) error { return nil },
+		Flags: []*Flag{
+			flag.New("pin", 0, "", flag.Secret),
+		},
+		Stdin: strings.NewReader("hunter2"),
+	}
+
+	err = Run(c, []string{"app", "--pin=@" + pin}, nil)
+	assert.Error(t, err)
+	assert.False(t, strings.Contains(err.Error(), "hunter2"), "%v", err)
+
+	err = Run(c, []string{"app", "--pin", "@-"}, nil)
+	assert.Error(t, err)
+	assert.False(t, strings.Contains(err.Error(), "hunter2"), "%v", err)
+}
+
+func TestPromptRequired(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name:   "app",
+		Action: func(*Command) error { return nil },
+		Flags: []*Flag{
+			flag.New("name", "", "name to greet", flag.Required),
+			flag.New("token", "", "", flag.Required, flag.Secret),
+			flag.New("force", false, "", flag.Required),
+			NoInputFlag,
+		},
+		Stdin:  strings.NewReader("bob\nqwerty\nyes\n"),
+		Stdout: &buf,
+	}
+
+	err := Run(c, []string{"app"}, nil)
+	assert.ErrorIs(t, err, flag.ErrRequired, "stdin is not a terminal")
+
+	c.PromptFlag = AskFlag
+
+	err = Run(c, []string{"app"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", c.Flag("name").Value)
+	assert.Equal(t, "qwerty", c.Flag("token").Value)
+	assert.Equal(t, true, c.Flag("force").Value)
+	assert.Equal(t, "name (name to greet): token: force [y/N]: ", buf.String())
+}
+
+func TestPromptLiteral(t *testing.T) {
+	c := &Command{
+		Name:   "app",
+		Action: func(*Command) error { return nil },
+		Flags: []*Flag{
+			flag.New("token", "", "", flag.Required, flag.Secret),
+			flag.New("file", "", "", flag.Required, flag.ValueRef),
+			flag.New("at", "", "", flag.Required, flag.AtFile),
+		},
+		Stdin:      strings.NewReader("env:HOME\n@/etc/hostname\n@-\n"),
+		Stdout:     &bytes.Buffer{},
+		PromptFlag: AskFlag,
+	}
+
+	err := Run(c, []string{"app"}, []string{"HOME=/root"})
+	assert.NoError(t, err)
+	assert.Equal(t, "env:HOME", c.Flag("token").Value)
+	assert.Equal(t, "@/etc/hostname", c.Flag("file").Value)
+	assert.Equal(t, "@-", c.Flag("at").Value)
+	assert.True(t, c.Flag("token").CurrentCommand == c)
+}
+
+func TestInteractive(t *testing.T) {
+	defer func(old func(interface{}) bool) { isTerminal = old }(isTerminal)
+
+	isTerminal = func(interface{}) bool { return true }
+
+	c := &Command{
+		Name:  "app",
+		Flags: []*Flag{NoInputFlag},
+	}
+
+	for _, tc := range []struct {
+		args []string
+		env  []string
+		exp  bool
+	}{
+		{args: []string{"app"}, exp: true},
+		{args: []string{"app", "--no-input"}, exp: false},
+		{args: []string{"app", "--no-input=false"}, exp: true},
+		{args: []string{"app"}, env: []string{"CI=1"}, exp: false},
+		{args: []string{"app"}, env: []string{"CI=true"}, exp: false},
+		{args: []string{"app"}, env: []string{"CI=false"}, exp: true},
+		{args: []string{"app"}, exp: true},
+	} {
+		_, err := Parse(c, tc.args, tc.env)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.exp, c.Interactive(), "args %q  env %q", tc.args, tc.env)
+	}
+}
+
+func TestAfterCommand(t *testing.T) {
+	var after []string
+
+	record := func(c *Command) error {
+		after = append(after, c.MainName())
+		return nil
+	}
+
+	c := &Command{
+		Name:  "app",
+		After: record,
+		Commands: []*Command{{
+			Name:   "sub",
+			After:  record,
+			Action: func(*Command) error { return nil },
+		}},
+	}
+
+	err := Run(c, []string{"app", "sub"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sub", "app"}, after)
+}
+
+func TestFlagDefaults(t *testing.T) {
+	c := &Command{
+		Name:   "app",
+		Action: func(*Command) error { return nil },
+		Flags: []*Flag{
+			flag.New("level", 1, ""),
+			flag.New("tag", []string{"a"}, ""),
+			flag.New("mode", &testSetter{v: "info"}, ""),
+		},
+	}
+
+	err := Run(c, []string{"app", "--level=5", "--tag=b", "--mode=debug"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, c.Flag("level").Value)
+	assert.Equal(t, "debug", c.Flag("mode").Value.(*testSetter).v)
+
+	assert.Equal(t, "1", c.Flag("level").DefaultString())
+	assert.Equal(t, "a", c.Flag("tag").DefaultString())
+	assert.Equal(t, "info", c.Flag("mode").DefaultString(), "setter default is saved as text")
+
+	c.Flag("level").Value = 3
+
+	err = Run(c, []string{"app"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, c.Flag("level").Value, "value set by the caller is kept")
+	assert.Equal(t, "3", c.Flag("level").DefaultString())
+}
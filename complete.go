@@ -2,64 +2,231 @@ package cli
 
 import (
 	"errors"
-	"fmt"
-	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
 
 	"nikand.dev/go/cli/complete"
 )
 
-var ErrCouldNotDetermineShell = errors.New("couldn't determine the shell")
+var (
+	ErrCouldNotDetermineShell = errors.New("couldn't determine the shell")
+	ErrNoHome                 = errors.New("no home dir")
+)
 
 var CompleteCmd = &Command{
 	Name:        "_complete",
-	Usage:       "[bash|zsh]",
+	Usage:       "[bash|zsh|fish|pwsh|nu]",
 	Description: "print shell completion script",
 	Action:      completeAuto,
 	Hidden:      true,
 	Commands: []*Command{{
 		Name:   "bash",
-		Action: completeBash,
+		Action: completeShell,
 	}, {
 		Name:   "zsh",
-		Action: completeZsh,
-	}},
+		Action: completeShell,
+	}, {
+		Name:   "fish",
+		Action: completeShell,
+	}, {
+		Name:   "pwsh,powershell",
+		Action: completeShell,
+	}, {
+		Name:   "nu,nushell",
+		Action: completeShell,
+	}, completeInstallCmd, completeUninstallCmd},
 }
 
-func beforeComplete(c *Command) (err error) {
-	fn := "complete_log_app"
-	fn, err = filepath.Abs(fn)
-	if err != nil {
-		return wrap(err, "abs path")
+// runComplete completes the command line taken from CLI_COMP_* env vars set by the completion script.
+// The line is parsed up to the word being completed, and the deepest chosen command is completed.
+// No Before, Action or After is run. Flag actions are not run either, except typed value parsers,
+// so completion has no side effects.
+func runComplete(app *Command, env []string) error {
+	words, idx := complete.Args(app)
+	if idx > len(words) {
+		idx = len(words)
 	}
 
-	f, err := os.Create(fn)
-	if err != nil {
-		return wrap(err, "create log file")
+	if idx == 0 {
+		return nil
 	}
 
-	defer f.Close()
+	used := map[*Flag]bool{}
 
-	for _, e := range c.Env {
-		if strings.HasPrefix(e, "CLI_COMP_") {
-			fmt.Fprintf(f, "%v\n", e)
+	c := parseComplete(app, words[:idx], env, used)
+
+	return c.complete(used)
+}
+
+// parseComplete is parse for completion. See runComplete.
+// Flags given in args are added to used.
+func parseComplete(c *Command, args, env []string, used map[*Flag]bool) *Command {
+	c.OSArgs = args
+	c.OSEnv = env
+
+	c.Arg0 = args[0]
+	args = args[1:]
+
+	c.setup()
+	c.prepareFlags()
+
+	c.Env = env
+
+	for len(args) != 0 {
+		arg := args[0]
+
+		if arg == "--" {
+			if c.Args != nil {
+				c.Args = append(c.Args, args[1:]...)
+			}
+
+			break
+		}
+
+		if arg != "" && arg[0] == '-' && arg != "-" {
+			args = completeSkipFlag(c, arg, args[1:], used)
+
+			continue
+		}
+
+		if sub := c.Command(arg); sub != nil {
+			c.Chosen = sub
+
+			return parseComplete(sub, args, env, used)
 		}
+
+		if c.Args != nil {
+			c.Args = append(c.Args, arg)
+		}
+
+		args = args[1:]
 	}
 
-	//	return errors.New("we were here")
-	return nil
+	return c
+}
+
+// completeSkipFlag skips the flag and its value.
+// Typed value parsers are run, so flag values are available to completers.
+// For other flags the value is expected in the next arg if flagTakesValue and it's not given after =.
+// The flag is added to used, so it's not offered again unless it's Repeatable.
+func completeSkipFlag(c *Command, arg string, args []string, used map[*Flag]bool) []string {
+	f := c.Flag(flagName(arg))
+	if f == nil {
+		return args
+	}
+
+	used[f] = true
+
+	if f.TypedAction() {
+		f.CurrentCommand = c
+
+		rest, err := f.Action(f, arg, args)
+		if err == nil {
+			return rest
+		}
+	}
+
+	if !flagTakesValue(f) || strings.ContainsAny(arg, "= ") || len(args) == 0 {
+		return args
+	}
+
+	return args[1:]
 }
 
+// completeFlagName returns the flag name to offer for the typed dashes and name prefix.
+// A single dash is completed to the short name, or to the long one if there is no short name and nothing more is typed.
+// Two dashes are completed to the long name only.
+func completeFlagName(c *Command, f *Flag, dashes, cur string) string {
+	var short, long string
+
+	for _, name := range strings.Split(f.Name, ",") {
+		if c.Flag(name) != f || !completeMatch(name, cur, f.Hidden) {
+			continue
+		}
+
+		if len(name) == 1 && short == "" {
+			short = name
+		} else if len(name) > 1 && long == "" {
+			long = name
+		}
+	}
+
+	switch {
+	case len(dashes) == 1 && short != "":
+		return "-" + short
+	case len(dashes) == 1 && cur == "" && long != "":
+		return "--" + long
+	case len(dashes) > 1 && long != "":
+		return "--" + long
+	}
+
+	return ""
+}
+
+// completeMatch reports whether the command or flag name is offered for the prefix.
+// Hidden ones and names starting with _ are only offered if the prefix starts with _ as well.
+func completeMatch(name, cur string, hidden bool) bool {
+	if (hidden || strings.HasPrefix(name, "_")) && !strings.HasPrefix(cur, "_") {
+		return false
+	}
+
+	return strings.HasPrefix(name, cur)
+}
+
+// DefaultComplete completes the word being completed:
+// subcommands and positional args using Command.Complete,
+// flags of the command and its parents,
+// or the flag value using flag.Flag.Complete for --flag <TAB> and --flag=<TAB>.
+// Candidates are printed in the complete package protocol format.
+// All the flags are offered as it's not known which are already used,
+// Run in completion mode skips them.
 func DefaultComplete(c *Command) (err error) {
-	var repl []string
+	return defaultComplete(c, nil)
+}
 
+func defaultComplete(c *Command, used map[*Flag]bool) (err error) {
 	current := complete.Current(c)
 
-	if false && current == "" {
-		_, err = defaultHelp(nil, "", nil)
-		return
+	repl, d, err := completeCandidates(c, current, used)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range repl {
+		if v, _, _ := complete.SplitCandidate(r); strings.HasSuffix(v, "/") || strings.HasSuffix(v, "=") {
+			d |= complete.NoSpace
+		}
+	}
+
+	err = complete.Write(c.Stdout, repl, d)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
+
+func completeCandidates(c *Command, current string, used map[*Flag]bool) (repl []string, d complete.Directive, err error) {
+	if completeAfterDashDash(c) {
+		return completeArgs(c, current, nil, 0)
+	}
+
+	if f, prefix, keep := completeValueFlag(c, current); f != nil {
+		if f.Complete == nil {
+			return nil, complete.FileFallback, nil
+		}
+
+		f.CurrentCommand = c
+
+		vals, err := f.Complete(f, prefix)
+		if err != nil {
+			return nil, 0, wrap(err, "complete %v", f.MainName())
+		}
+
+		for _, v := range vals {
+			repl = append(repl, keep+v)
+		}
+
+		return repl, 0, nil
 	}
 
 	var dashes string
@@ -74,54 +241,121 @@ func DefaultComplete(c *Command) (err error) {
 		cur = cur[i:]
 	}
 
+	d = complete.KeepOrder
+
 	if dashes == "" {
-		for _, sub := range c.Commands {
-		cmd:
+		for _, sub := range groupedCommands(c) {
 			for _, name := range strings.Split(sub.Name, ",") {
-				if strings.HasPrefix(name, "_") != strings.HasPrefix(cur, "_") {
-					continue
-				}
+				if completeMatch(name, cur, sub.Hidden) {
+					repl = append(repl, complete.Candidate(name, sub.Description, completeGroup(sub.Group, "Commands")))
 
-				if strings.HasPrefix(name, cur) {
-					repl = append(repl, name)
-
-					break cmd
+					break
 				}
 			}
 		}
-	} else {
-		for _, f := range c.Flags {
-		flg:
-			for _, name := range strings.Split(f.Name, ",") {
-				if (len(dashes) > 1) && (len(name) == 1) {
-					continue
-				}
 
-				if strings.HasPrefix(name, cur) {
-					dd := "--"
-					if len(name) == 1 {
-						dd = "-"
-					}
+		return completeArgs(c, current, repl, d)
+	}
 
-					repl = append(repl, dd+name)
+	for _, f := range scopeFlags(c) {
+		if used[f] && !f.Repeatable() {
+			continue
+		}
 
-					break flg
-				}
-			}
+		if name := completeFlagName(c, f, dashes, cur); name != "" {
+			repl = append(repl, complete.Candidate(name, f.Description, completeGroup(f.Group, "Flags")))
 		}
 	}
 
-	//	tlog.Printw("complete", "dashes", dashes, "cur", cur, "opts", repl)
+	return repl, d, nil
+}
+
+// completeArgs adds positional args candidates.
+func completeArgs(c *Command, current string, repl []string, d complete.Directive) ([]string, complete.Directive, error) {
+	switch {
+	case c.Args == nil:
+	case c.Complete != nil:
+		args, err := c.Complete(c, current)
+		if err != nil {
+			return nil, 0, wrap(err, "complete args")
+		}
 
-	for i := range repl {
-		repl[i] = strconv.Quote(repl[i])
+		repl = append(repl, args...)
+	default:
+		d |= complete.FileFallback
 	}
 
-	fmt.Fprintf(c.Stdout, "COMPREPLY=( $(compgen -W '%[2]s' -- %[1]s) )", current, strings.Join(repl, " "))
+	return repl, d, nil
+}
 
-	//	fmt.Fprintf(c, "COMPREPLY=(%s)", strings.Join(repl, " "))
+// completeAfterDashDash reports whether the word being completed follows --,
+// so it can only be a positional arg.
+func completeAfterDashDash(c *Command) bool {
+	words, idx := complete.Args(c)
 
-	return nil
+	for i := 1; i < idx && i < len(words); i++ {
+		if words[i] == "--" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// completeValueFlag finds the flag which value is being completed.
+// These forms are recognized: --flag=<TAB>, --flag <TAB>,
+// and --flag = <TAB> as bash splits words on =.
+// The value prefix and the current word part to keep in candidates are returned.
+func completeValueFlag(c *Command, current string) (f *Flag, prefix, keep string) {
+	words, idx := complete.Args(c)
+
+	var prev, prev2 string
+
+	if idx >= 1 && idx <= len(words) {
+		prev = words[idx-1]
+	}
+
+	if idx >= 2 && idx <= len(words) {
+		prev2 = words[idx-2]
+	}
+
+	isFlag := func(arg string) bool {
+		return strings.HasPrefix(arg, "-") && arg != "-" && arg != "--"
+	}
+
+	switch {
+	case isFlag(current) && strings.Contains(current, "="):
+		p := strings.IndexByte(current, '=')
+
+		return c.Flag(flagName(current)), current[p+1:], current[:p+1]
+	case current == "=" && isFlag(prev) && !strings.Contains(prev, "="):
+		return c.Flag(flagName(prev)), "", "="
+	case prev == "=" && isFlag(prev2) && !strings.Contains(prev2, "="):
+		return c.Flag(flagName(prev2)), current, ""
+	case isFlag(prev) && !strings.ContainsAny(prev, "= "):
+		f = c.Flag(flagName(prev))
+		if f != nil && flagTakesValue(f) {
+			return f, current, ""
+		}
+	}
+
+	return nil, "", ""
+}
+
+func completeGroup(group, def string) string {
+	if group == "" {
+		return def
+	}
+
+	return group
+}
+
+// flagTakesValue reports whether the flag value is expected in the next arg.
+// It's not for bool flags and for flags with optional values, which placeholder is in brackets like =[hidden].
+func flagTakesValue(f *Flag) bool {
+	p := f.Placeholder()
+
+	return p != "" && !strings.HasPrefix(p, "=[")
 }
 
 func completeAuto(c *Command) error {
@@ -138,20 +372,12 @@ func completeAuto(c *Command) error {
 	return complete.ExecTemplate(c.Stdout, sh, root.OSArgs)
 }
 
-func completeBash(c *Command) error {
-	root := c
-	for root.Parent != nil {
-		root = root.Parent
-	}
-
-	return complete.ExecTemplate(c.Stdout, "bash", root.OSArgs)
-}
-
-func completeZsh(c *Command) error {
+// completeShell prints the script for the shell named by the command.
+func completeShell(c *Command) error {
 	root := c
 	for root.Parent != nil {
 		root = root.Parent
 	}
 
-	return complete.ExecTemplate(c.Stdout, "zsh", root.OSArgs)
+	return complete.ExecTemplate(c.Stdout, c.MainName(), root.OSArgs)
 }
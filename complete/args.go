@@ -16,24 +16,46 @@ type (
 	}
 )
 
+// Shell detects the user shell.
+// $SHELL is used if it names a supported shell.
+// Otherwise shell specific env vars are checked: BASH, ZSH_NAME, NU_VERSION and PSModulePath.
+// They are only a fallback, as PSModulePath is set system-wide on Windows
+// and others are inherited by child shells, while BASH and ZSH_NAME are usually not exported.
+// Unsupported $SHELL name is returned if nothing else is found.
 func Shell(env LookupEnver) (sh string, ok bool) {
-	_, ok = env.LookupEnv("BASH")
-	if ok {
-		//	return filepath.Base(sh), true
-		return "bash", true
+	path, _ := env.LookupEnv("SHELL")
+	if path != "" {
+		sh = ShellName(filepath.Base(path))
+
+		if _, err := Template(sh); err == nil {
+			return sh, true
+		}
 	}
 
-	_, ok = env.LookupEnv("ZSH_NAME")
-	if ok {
-		return "zsh", true
+	for _, v := range []struct{ env, sh string }{
+		{"BASH", "bash"},
+		{"ZSH_NAME", "zsh"},
+		{"NU_VERSION", "nu"},
+		{"PSModulePath", "pwsh"},
+	} {
+		if _, ok := env.LookupEnv(v.env); ok {
+			return v.sh, true
+		}
 	}
 
-	_, ok = env.LookupEnv("SHELL")
-	if ok {
-		return filepath.Base(sh), true
+	return sh, sh != ""
+}
+
+// ShellName returns the script name for the shell name or alias.
+func ShellName(sh string) string {
+	switch sh {
+	case "powershell", "pwsh.exe", "powershell.exe":
+		return "pwsh"
+	case "nushell":
+		return "nu"
 	}
 
-	return "", false
+	return sh
 }
 
 func Current(env LookupEnver) (cur string) {
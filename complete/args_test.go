@@ -0,0 +1,59 @@
+package complete
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nikandfor/assert"
+)
+
+type testEnv map[string]string
+
+func (e testEnv) LookupEnv(k string) (v string, ok bool) {
+	v, ok = e[k]
+	return
+}
+
+func TestShell(t *testing.T) {
+	for _, tc := range []struct {
+		env testEnv
+		sh  string
+		ok  bool
+	}{
+		{testEnv{"BASH": "/bin/bash", "SHELL": "/bin/zsh"}, "zsh", true},
+		{testEnv{"BASH": "/bin/bash", "SHELL": "/bin/sh"}, "bash", true},
+		{testEnv{"ZSH_NAME": "zsh"}, "zsh", true},
+		{testEnv{"SHELL": "/usr/bin/zsh"}, "zsh", true},
+		{testEnv{"SHELL": "/usr/bin/fish"}, "fish", true},
+		{testEnv{"SHELL": "/usr/bin/powershell"}, "pwsh", true},
+		{testEnv{"NU_VERSION": "0.90.0", "SHELL": "/bin/bash"}, "bash", true},
+		{testEnv{"NU_VERSION": "0.90.0"}, "nu", true},
+		{testEnv{"PSModulePath": `C:\Program Files\PowerShell\Modules`, "SHELL": "/bin/bash"}, "bash", true},
+		{testEnv{"PSModulePath": `C:\Program Files\PowerShell\Modules`, "SHELL": "/usr/bin/tcsh"}, "pwsh", true},
+		{testEnv{"PSModulePath": `C:\Program Files\PowerShell\Modules`}, "pwsh", true},
+		{testEnv{"SHELL": "/usr/bin/tcsh"}, "tcsh", true},
+		{testEnv{"SHELL": ""}, "", false},
+		{testEnv{}, "", false},
+	} {
+		sh, ok := Shell(tc.env)
+		assert.Equal(t, tc.sh, sh, "%v", tc.env)
+		assert.Equal(t, tc.ok, ok, "%v", tc.env)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	for _, sh := range []string{"bash", "zsh", "fish", "pwsh", "powershell", "nu"} {
+		var b bytes.Buffer
+
+		err := ExecTemplate(&b, sh, []string{"/usr/bin/app", "_complete", sh})
+		assert.NoError(t, err, sh)
+
+		assert.True(t, !strings.Contains(b.String(), "%!"), "bad format verbs: %v", sh)
+		assert.True(t, strings.Contains(b.String(), "# app "), "%v", sh)
+		assert.True(t, strings.Contains(b.String(), "/usr/bin/app _complete "+sh), "%v", sh)
+	}
+
+	_, err := Template("tcsh")
+	assert.ErrorIs(t, err, ErrUnsupportedShell)
+}
@@ -1,30 +1,50 @@
-
 # %[1]s bash completion
 _nikandcli_complete_bash() {
-	declare -x CLI_COMP_BASE=$1
-	declare -x CLI_COMP_CUR=$2
-	declare -x CLI_COMP_PREV=$3
+	local out directive line cand
+	local -a lines
+
+	out=$(
+		export CLI_COMP_BASE="$1"
+		export CLI_COMP_CUR="$2"
+		export CLI_COMP_PREV="$3"
+
+		export CLI_COMP_LINE="$COMP_LINE"
+		export CLI_COMP_INDEX="$COMP_POINT"
+
+		export CLI_COMP_WORDS_LENGTH="${#COMP_WORDS[@]}"
+		export CLI_COMP_WORDS_INDEX="$COMP_CWORD"
 
-	declare -x CLI_COMP_LINE=$COMP_LINE
-	declare -x CLI_COMP_INDEX=$COMP_POINT
+		for i in "${!COMP_WORDS[@]}"; do
+			export "CLI_COMP_WORDS_${i}=${COMP_WORDS[$i]}"
+		done
 
-	declare -x CLI_COMP_WORDS_LENGTH=${#COMP_WORDS[@]}
-	declare -x CLI_COMP_WORDS_INDEX=$COMP_CWORD
+		"$1" 2>/dev/null
+	) || return
 
-	for i in $(seq 0 $(("${#COMP_WORDS[@]}" - 1)) ); do
-		declare -x "CLI_COMP_WORDS_${i}"="${COMP_WORDS[$i]}"
+	mapfile -t lines <<<"$out"
+
+	directive="${lines[${#lines[@]}-1]}"
+	unset "lines[${#lines[@]}-1]"
+
+	COMPREPLY=()
+
+	for line in "${lines[@]}"; do
+		IFS=$'\t' read -r cand _ <<<"$line"
+		[[ -n "$cand" ]] && COMPREPLY+=("$cand")
 	done
 
-	cmd=$("$1" "${COMP_WORDS[@]:1:$COMP_CWORD}")
+	[[ " ${directive#:} " == *" nospace "* ]] && compopt -o nospace
+	[[ " ${directive#:} " == *" keeporder "* ]] && compopt -o nosort 2>/dev/null
 
-	eval "$cmd"
+	if [[ " ${directive#:} " == *" files "* && ${#COMPREPLY[@]} -eq 0 ]]; then
+		compopt -o default
+	fi
 }
 
-complete -F _nikandcli_complete_bash greeter # %[2]s
-
-#_nikandcli_complete_bash "$@"
+complete -F _nikandcli_complete_bash %[1]s # %[2]s
 
-# to persist bash completion add this to the end of your ~/.bashrc file by command:
-#   %[2]s >>~/.bashrc
+# to persist bash completion use the install subcommand of the completion command
+# or put the script to the bash-completion user dir:
+#   %[2]s >"${BASH_COMPLETION_USER_DIR:-${XDG_DATA_HOME:-$HOME/.local/share}/bash-completion}/completions/%[1]s"
 # or alternatively to enable it to only current session use command:
 #   source <(%[2]s)
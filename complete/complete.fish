@@ -0,0 +1,46 @@
+# %[1]s fish completion
+
+function __nikandcli_complete_fish
+	set -l cur (commandline -ct)
+	set -l words (commandline -opc) "$cur"
+	set -l n (count $words)
+
+	set -l vars CLI_COMP_BASE=$words[1] CLI_COMP_CUR="$cur" \
+		CLI_COMP_LINE=(commandline -p) CLI_COMP_INDEX=(commandline -C) \
+		CLI_COMP_WORDS_LENGTH=$n CLI_COMP_WORDS_INDEX=(math $n - 1)
+
+	if test $n -gt 1
+		set -a vars CLI_COMP_PREV=$words[-2]
+	end
+
+	for i in (seq $n)
+		set -a vars CLI_COMP_WORDS_(math $i - 1)=$words[$i]
+	end
+
+	set -l out (env $vars $words[1] 2>/dev/null)
+	or return
+
+	set -l directive " "(string sub -s 2 -- $out[-1])" "
+	set -e out[-1]
+
+	for line in $out
+		set -l parts (string split \t -- $line)
+
+		if test -n "$parts[2]"
+			printf '%%s\t%%s\n' $parts[1] $parts[2]
+		else if test -n "$parts[1]"
+			printf '%%s\n' $parts[1]
+		end
+	end
+
+	if test (count $out) -eq 0; and string match -q '* files *' -- $directive
+		__fish_complete_path "$cur"
+	end
+end
+
+complete -c %[1]s -f -k -a '(__nikandcli_complete_fish)' # %[2]s
+
+# to enable fish completion for the current session use command:
+#   %[2]s | source
+# or to persist it use the install subcommand of the completion command or:
+#   %[2]s >~/.config/fish/completions/%[1]s.fish
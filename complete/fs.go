@@ -2,16 +2,27 @@ package complete
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"path/filepath"
 	"strings"
 )
 
-//go:embed complete.bash complete.zsh
+//go:embed complete.bash complete.zsh complete.fish complete.pwsh complete.nu
 var scripts embed.FS
 
+var ErrUnsupportedShell = errors.New("unsupported shell")
+
+// Template returns the completion script template for the shell: bash, zsh, fish, pwsh or nu.
 func Template(shell string) ([]byte, error) {
-	return scripts.ReadFile("complete." + shell)
+	data, err := scripts.ReadFile("complete." + ShellName(shell))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedShell, shell)
+	}
+
+	return data, err
 }
 
 func ExecTemplate(w io.Writer, shell string, args []string) (err error) {
@@ -20,7 +31,7 @@ func ExecTemplate(w io.Writer, shell string, args []string) (err error) {
 		return fmt.Errorf("read script: %w", err)
 	}
 
-	_, err = fmt.Fprintf(w, string(f), args[0], strings.Join(args, " "))
+	_, err = fmt.Fprintf(w, string(f), filepath.Base(args[0]), strings.Join(args, " "))
 
 	return
 }
@@ -0,0 +1,141 @@
+package complete
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Protocol
+//
+// In completion mode the program prints one candidate per line:
+// the value, optionally followed by a tab and a description,
+// optionally followed by a tab and a group name.
+// The last line is a colon followed by space separated directives.
+//
+//	deploy	deploy the app	Commands
+//	describe		Commands
+//	--verbose	verbose output	Flags
+//	:keeporder
+//
+// The embedded shell scripts interpret it, so all the shells share the same Go implementation.
+
+type (
+	Directive uint
+)
+
+// Directives.
+const (
+	NoSpace      Directive = 1 << iota // do not add a space after the completed word
+	FileFallback                       // use shell file completion if there are no candidates
+	KeepOrder                          // do not sort candidates
+
+	DefaultDirective Directive = 0
+)
+
+var ErrNoDirective = errors.New("no directive line")
+
+var directiveNames = []string{"nospace", "files", "keeporder"}
+
+// Candidate formats a protocol candidate line.
+// Only the first line of the description is used.
+// Group is used by shells which can show candidates grouped.
+func Candidate(value, desc, group string) string {
+	if p := strings.IndexByte(desc, '\n'); p != -1 {
+		desc = desc[:p]
+	}
+
+	r := strings.NewReplacer("\t", " ", "\n", " ")
+
+	value = r.Replace(value)
+	desc = r.Replace(desc)
+	group = r.Replace(group)
+
+	switch {
+	case group != "":
+		return value + "\t" + desc + "\t" + group
+	case desc != "":
+		return value + "\t" + desc
+	}
+
+	return value
+}
+
+// SplitCandidate splits a candidate line into the value, the description and the group.
+func SplitCandidate(c string) (value, desc, group string) {
+	s := strings.SplitN(c, "\t", 3)
+
+	value = s[0]
+
+	if len(s) > 1 {
+		desc = s[1]
+	}
+
+	if len(s) > 2 {
+		group = s[2]
+	}
+
+	return
+}
+
+// Write writes the candidates and the directive in the protocol format.
+// Candidates are lines made by Candidate or plain values.
+func Write(w io.Writer, cands []string, d Directive) error {
+	bw := bufio.NewWriter(w)
+
+	for _, c := range cands {
+		if c == "" {
+			continue
+		}
+
+		fmt.Fprintf(bw, "%s\n", c)
+	}
+
+	fmt.Fprintf(bw, ":%s\n", d)
+
+	return bw.Flush()
+}
+
+// String returns space separated directive names.
+func (d Directive) String() string {
+	var names []string
+
+	for i, n := range directiveNames {
+		if d&(1<<i) != 0 {
+			names = append(names, n)
+		}
+	}
+
+	return strings.Join(names, " ")
+}
+
+// Parse parses the protocol output. Unknown directives are ignored.
+func Parse(data []byte) (cands []string, d Directive, err error) {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	last := lines[len(lines)-1]
+	if !strings.HasPrefix(last, ":") {
+		return nil, 0, ErrNoDirective
+	}
+
+	for _, n := range strings.Fields(last[1:]) {
+		i := 0
+		for i < len(directiveNames) && directiveNames[i] != n {
+			i++
+		}
+
+		if i < len(directiveNames) {
+			d |= 1 << i
+		}
+	}
+
+	for _, l := range lines[:len(lines)-1] {
+		if l != "" {
+			cands = append(cands, l)
+		}
+	}
+
+	return cands, d, nil
+}
@@ -0,0 +1,44 @@
+package complete
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/nikandfor/assert"
+)
+
+func TestProtocol(t *testing.T) {
+	var b bytes.Buffer
+
+	err := Write(&b, []string{
+		Candidate("deploy", "deploy the app\nsecond line", "Commands"),
+		Candidate("dir/", "", ""),
+		Candidate("with\ttab", "desc\twith tab", ""),
+		Candidate("--verbose", "", "Flags"),
+	}, NoSpace|KeepOrder)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "deploy\tdeploy the app\tCommands\ndir/\nwith tab\tdesc with tab\n--verbose\t\tFlags\n:nospace keeporder\n", b.String())
+
+	cands, d, err := Parse(b.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, NoSpace|KeepOrder, d)
+	assert.Equal(t, []string{"deploy\tdeploy the app\tCommands", "dir/", "with tab\tdesc with tab", "--verbose\t\tFlags"}, cands)
+
+	v, desc, group := SplitCandidate(cands[0])
+	assert.Equal(t, "deploy", v)
+	assert.Equal(t, "deploy the app", desc)
+	assert.Equal(t, "Commands", group)
+
+	v, desc, group = SplitCandidate(cands[1])
+	assert.Equal(t, "dir/", v)
+	assert.Equal(t, "", desc)
+	assert.Equal(t, "", group)
+
+	_, d, err = Parse([]byte(":files future\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, FileFallback, d)
+
+	_, _, err = Parse([]byte("value\n"))
+	assert.ErrorIs(t, err, ErrNoDirective)
+}
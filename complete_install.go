@@ -0,0 +1,355 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nikand.dev/go/cli/complete"
+	"nikand.dev/go/cli/flag"
+)
+
+type (
+	// CompleteInstall is where the completion script for a shell is installed.
+	CompleteInstall struct {
+		Shell string
+		Name  string // program name
+
+		// Path is the script file.
+		Path string
+
+		// RC is the shell rc file the Block is added to between marker comments.
+		// Empty if the shell loads scripts from Path by itself.
+		RC    string
+		Block string
+	}
+)
+
+var (
+	completeInstallCmd = &Command{
+		Name:         "install",
+		Usage:        "[bash|zsh|fish]",
+		Description:  "install completion script to the shell's completion dir",
+		Args:         Args{},
+		Action:       completeInstallAction,
+		Flags:        completeInstallFlags(),
+		builtinFlags: completeInstallFlags,
+	}
+
+	completeUninstallCmd = &Command{
+		Name:         "uninstall",
+		Usage:        "[bash|zsh|fish]",
+		Description:  "remove installed completion script",
+		Args:         Args{},
+		Action:       completeUninstallAction,
+		Flags:        completeInstallFlags(),
+		builtinFlags: completeInstallFlags,
+	}
+)
+
+// CompleteInstallFor returns where the completion script is installed for the shell.
+// Paths are taken from the command env:
+//
+//	bash - $BASH_COMPLETION_USER_DIR/completions/name or $XDG_DATA_HOME/bash-completion/completions/name
+//	zsh  - $XDG_DATA_HOME/zsh/site-functions/_name added to fpath in $ZDOTDIR/.zshrc
+//	fish - $XDG_CONFIG_HOME/fish/completions/name.fish
+//
+// XDG_DATA_HOME defaults to ~/.local/share and XDG_CONFIG_HOME to ~/.config.
+func CompleteInstallFor(c *Command, shell, name string) (*CompleteInstall, error) {
+	home := c.Getenv("HOME")
+
+	xdg := func(key, def string) (string, error) {
+		if v := c.Getenv(key); v != "" {
+			return v, nil
+		}
+
+		if home == "" {
+			return "", wrap(ErrNoHome, "%v", key)
+		}
+
+		return filepath.Join(home, def), nil
+	}
+
+	in := &CompleteInstall{
+		Shell: complete.ShellName(shell),
+		Name:  name,
+	}
+
+	switch in.Shell {
+	case "bash":
+		dir := c.Getenv("BASH_COMPLETION_USER_DIR")
+		if dir == "" {
+			data, err := xdg("XDG_DATA_HOME", ".local/share")
+			if err != nil {
+				return nil, err
+			}
+
+			dir = filepath.Join(data, "bash-completion")
+		}
+
+		in.Path = filepath.Join(dir, "completions", name)
+	case "zsh":
+		data, err := xdg("XDG_DATA_HOME", ".local/share")
+		if err != nil {
+			return nil, err
+		}
+
+		rcdir, err := xdg("ZDOTDIR", "")
+		if err != nil {
+			return nil, err
+		}
+
+		dir := filepath.Join(data, "zsh", "site-functions")
+
+		in.Path = filepath.Join(dir, "_"+name)
+		in.RC = filepath.Join(rcdir, ".zshrc")
+		in.Block = fmt.Sprintf("fpath=(%s $fpath)\n(( $+functions[compdef] )) && autoload -Uz _%s && compdef _%[2]s %[2]s\n", shellQuote(dir), name)
+	case "fish":
+		conf, err := xdg("XDG_CONFIG_HOME", ".config")
+		if err != nil {
+			return nil, err
+		}
+
+		in.Path = filepath.Join(conf, "fish", "completions", name+".fish")
+	default:
+		return nil, wrap(complete.ErrUnsupportedShell, "install %v", shell)
+	}
+
+	return in, nil
+}
+
+// Install writes the script to Path and adds Block to RC.
+// It's idempotent: the block is replaced if it's already there.
+// Changes are printed to c.Stdout. Nothing is changed if dry.
+func (in *CompleteInstall) Install(c *Command, script []byte, dry bool) error {
+	err := in.writeFile(c, in.Path, script, dry)
+	if err != nil {
+		return err
+	}
+
+	if in.RC == "" {
+		return nil
+	}
+
+	return in.updateRC(c, in.Block, dry)
+}
+
+// Uninstall removes the script and the RC block.
+// Changes are printed to c.Stdout. Nothing is changed if dry.
+func (in *CompleteInstall) Uninstall(c *Command, dry bool) error {
+	_, err := os.Stat(in.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		fmt.Fprintf(c.Stdout, "%v: not installed\n", in.Path)
+	case err != nil:
+		return wrap(err, "stat")
+	case dry:
+		fmt.Fprintf(c.Stdout, "would remove %v\n", in.Path)
+	default:
+		err = os.Remove(in.Path)
+		if err != nil {
+			return wrap(err, "remove")
+		}
+
+		fmt.Fprintf(c.Stdout, "removed %v\n", in.Path)
+	}
+
+	if in.RC == "" {
+		return nil
+	}
+
+	return in.updateRC(c, "", dry)
+}
+
+func (in *CompleteInstall) writeFile(c *Command, path string, data []byte, dry bool) error {
+	old, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return wrap(err, "read file")
+	}
+
+	switch {
+	case err == nil && bytes.Equal(old, data):
+		fmt.Fprintf(c.Stdout, "%v: up to date\n", path)
+		return nil
+	case dry:
+		fmt.Fprintf(c.Stdout, "would write %v\n", path)
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return wrap(err, "mkdir")
+	}
+
+	err = os.WriteFile(path, data, 0o644)
+	if err != nil {
+		return wrap(err, "write file")
+	}
+
+	fmt.Fprintf(c.Stdout, "wrote %v\n", path)
+
+	return nil
+}
+
+// updateRC sets the marked block in the RC file. Empty block removes it.
+func (in *CompleteInstall) updateRC(c *Command, block string, dry bool) error {
+	old, err := os.ReadFile(in.RC)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return wrap(err, "read rc file")
+	}
+
+	data, prev := setRCBlock(old, in.Name+" completion", block)
+
+	if bytes.Equal(old, data) {
+		fmt.Fprintf(c.Stdout, "%v: up to date\n", in.RC)
+		return nil
+	}
+
+	if dry {
+		fmt.Fprintf(c.Stdout, "would update %v\n", in.RC)
+	} else {
+		err = os.WriteFile(in.RC, data, 0o644)
+		if err != nil {
+			return wrap(err, "write rc file")
+		}
+
+		fmt.Fprintf(c.Stdout, "updated %v\n", in.RC)
+	}
+
+	for _, l := range strings.SplitAfter(prev, "\n") {
+		if l != "" {
+			fmt.Fprintf(c.Stdout, "-\t%s", l)
+		}
+	}
+
+	for _, l := range strings.SplitAfter(block, "\n") {
+		if l != "" {
+			fmt.Fprintf(c.Stdout, "+\t%s", l)
+		}
+	}
+
+	return nil
+}
+
+// setRCBlock replaces the block between marker comments, appends it if there is none,
+// or removes it if block is empty. The previous block content is returned.
+func setRCBlock(data []byte, name, block string) (_ []byte, prev string) {
+	start := "# >>> " + name + " >>>\n"
+	end := "# <<< " + name + " <<<\n"
+
+	var b []byte
+
+	if block != "" {
+		b = append(b, start...)
+		b = append(b, block...)
+
+		if !strings.HasSuffix(block, "\n") {
+			b = append(b, '\n')
+		}
+
+		b = append(b, end...)
+	}
+
+	s := bytes.Index(data, []byte(start))
+	if s != -1 && (s == 0 || data[s-1] == '\n') {
+		e := bytes.Index(data[s:], []byte(end))
+		if e != -1 {
+			prev = string(data[s+len(start) : s+e])
+
+			res := append([]byte{}, data[:s]...)
+			res = append(res, b...)
+			res = append(res, data[s+e+len(end):]...)
+
+			return res, prev
+		}
+	}
+
+	if block == "" {
+		return data, ""
+	}
+
+	res := append([]byte{}, data...)
+
+	if len(res) != 0 && res[len(res)-1] != '\n' {
+		res = append(res, '\n')
+	}
+
+	return append(res, b...), ""
+}
+
+func completeInstallFlags() []*Flag {
+	return []*Flag{
+		flag.New("dry-run,n", false, "print what would be changed"),
+	}
+}
+
+func completeInstallAction(c *Command) error {
+	in, root, err := completeInstallFor(c)
+	if err != nil {
+		return err
+	}
+
+	var script bytes.Buffer
+
+	args := []string{root.Arg0}
+	args = append(args, FullName(c.Parent)[1:]...)
+	args = append(args, in.Shell)
+
+	err = complete.ExecTemplate(&script, in.Shell, args)
+	if err != nil {
+		return err
+	}
+
+	return in.Install(c, script.Bytes(), c.Bool("dry-run"))
+}
+
+func completeUninstallAction(c *Command) error {
+	in, _, err := completeInstallFor(c)
+	if err != nil {
+		return err
+	}
+
+	return in.Uninstall(c, c.Bool("dry-run"))
+}
+
+func completeInstallFor(c *Command) (*CompleteInstall, *Command, error) {
+	root := c
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	var sh string
+
+	switch len(c.Args) {
+	case 0:
+		var ok bool
+
+		sh, ok = complete.Shell(c)
+		if !ok {
+			return nil, nil, ErrCouldNotDetermineShell
+		}
+	case 1:
+		sh = c.Args[0]
+	default:
+		return nil, nil, errors.New("expected at most one shell name")
+	}
+
+	in, err := CompleteInstallFor(c, sh, filepath.Base(root.Arg0))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return in, root, nil
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789/._-+") == "" {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
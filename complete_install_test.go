@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikandfor/assert"
+)
+
+func TestCompleteInstall(t *testing.T) {
+	home := t.TempDir()
+
+	var b bytes.Buffer
+
+	c := &Command{
+		Name:   "app",
+		Env:    []string{"HOME=" + home},
+		Stdout: &b,
+	}
+
+	in, err := CompleteInstallFor(c, "zsh", "app")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".local/share/zsh/site-functions/_app"), in.Path)
+	assert.Equal(t, filepath.Join(home, ".zshrc"), in.RC)
+
+	rc := "export EDITOR=vi"
+
+	err = os.WriteFile(in.RC, []byte(rc), 0o644)
+	assert.NoError(t, err)
+
+	err = in.Install(c, []byte("script\n"), true)
+	assert.NoError(t, err)
+	assert.Equal(t, "would write "+in.Path+"\nwould update "+in.RC+"\n+\t"+
+		"fpath=("+filepath.Dir(in.Path)+" $fpath)\n+\t(( $+functions[compdef] )) && autoload -Uz _app && compdef _app app\n", b.String())
+
+	_, err = os.Stat(in.Path)
+	assert.True(t, os.IsNotExist(err))
+
+	for i := 0; i < 2; i++ {
+		err = in.Install(c, []byte("script\n"), false)
+		assert.NoError(t, err)
+	}
+
+	data, err := os.ReadFile(in.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "script\n", string(data))
+
+	data, err = os.ReadFile(in.RC)
+	assert.NoError(t, err)
+	assert.Equal(t, rc+"\n# >>> app completion >>>\n"+in.Block+"# <<< app completion <<<\n", string(data))
+
+	b.Reset()
+
+	err = in.Uninstall(c, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "removed "+in.Path+"\nupdated "+in.RC+"\n-\t"+
+		"fpath=("+filepath.Dir(in.Path)+" $fpath)\n-\t(( $+functions[compdef] )) && autoload -Uz _app && compdef _app app\n", b.String())
+
+	data, err = os.ReadFile(in.RC)
+	assert.NoError(t, err)
+	assert.Equal(t, rc+"\n", string(data))
+
+	_, err = os.Stat(in.Path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCompleteInstallFor(t *testing.T) {
+	c := &Command{
+		Env: []string{"HOME=/home/u", "XDG_CONFIG_HOME=/conf"},
+	}
+
+	in, err := CompleteInstallFor(c, "bash", "app")
+	assert.NoError(t, err)
+	assert.Equal(t, "/home/u/.local/share/bash-completion/completions/app", in.Path)
+	assert.Equal(t, "", in.RC)
+
+	in, err = CompleteInstallFor(c, "fish", "app")
+	assert.NoError(t, err)
+	assert.Equal(t, "/conf/fish/completions/app.fish", in.Path)
+
+	c.Env = append(c.Env, "BASH_COMPLETION_USER_DIR=/bc")
+
+	in, err = CompleteInstallFor(c, "bash", "app")
+	assert.NoError(t, err)
+	assert.Equal(t, "/bc/completions/app", in.Path)
+
+	_, err = CompleteInstallFor(c, "pwsh", "app")
+	assert.Error(t, err)
+
+	_, err = CompleteInstallFor(&Command{}, "bash", "app")
+	assert.ErrorIs(t, err, ErrNoHome)
+}
+
+func TestCompleteInstallCmd(t *testing.T) {
+	home := t.TempDir()
+
+	defer func() {
+		CompleteCmd.Stdout = nil
+		completeInstallCmd.Stdout = nil
+		completeUninstallCmd.Stdout = nil
+	}()
+
+	var b bytes.Buffer
+
+	app := &Command{
+		Name:     "app",
+		Stdout:   &b,
+		Commands: []*Command{CompleteCmd},
+	}
+
+	err := Run(app, []string{"/usr/bin/app", "_complete", "install"}, []string{"HOME=" + home, "SHELL=/bin/fish"})
+	assert.NoError(t, err)
+
+	p := filepath.Join(home, ".config/fish/completions/app.fish")
+
+	assert.Equal(t, "wrote "+p+"\n", b.String())
+
+	data, err := os.ReadFile(p)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Contains(data, []byte("complete -c app ")))
+	assert.True(t, bytes.Contains(data, []byte("# /usr/bin/app _complete fish\n")))
+
+	env := []string{"HOME=" + home, "SHELL=/bin/zsh"}
+
+	b.Reset()
+
+	err = Run(app, []string{"/usr/bin/app", "_complete", "uninstall", "--dry-run", "fish"}, env)
+	assert.NoError(t, err)
+	assert.Equal(t, "would remove "+p+"\n", b.String())
+
+	b.Reset()
+
+	err = Run(app, []string{"/usr/bin/app", "_complete", "uninstall"}, env)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(b.String(), filepath.Join(home, ".local/share/zsh/site-functions/_app")+": not installed\n"),
+		"neither --dry-run nor the shell arg is kept: %q", b.String())
+
+	_, err = os.Stat(p)
+	assert.NoError(t, err)
+}
+
+func TestSetRCBlock(t *testing.T) {
+	data, prev := setRCBlock([]byte("a\n# >>> x >>>\nold\n# <<< x <<<\nb\n"), "x", "new")
+	assert.Equal(t, "a\n# >>> x >>>\nnew\n# <<< x <<<\nb\n", string(data))
+	assert.Equal(t, "old\n", prev)
+
+	data, _ = setRCBlock(data, "x", "")
+	assert.Equal(t, "a\nb\n", string(data))
+
+	data, _ = setRCBlock(data, "x", "")
+	assert.Equal(t, "a\nb\n", string(data))
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikandfor/assert"
+	"nikand.dev/go/cli/flag"
+)
+
+func completeEnv(idx int, words ...string) (env []string) {
+	env = append(env,
+		fmt.Sprintf("CLI_COMP_WORDS_INDEX=%d", idx),
+		fmt.Sprintf("CLI_COMP_WORDS_LENGTH=%d", len(words)),
+		"CLI_COMP_CUR="+words[idx],
+	)
+
+	for i, w := range words {
+		env = append(env, fmt.Sprintf("CLI_COMP_WORDS_%d=%s", i, w))
+	}
+
+	return env
+}
+
+func TestCompleteRun(t *testing.T) {
+	var buf bytes.Buffer
+
+	fail := func(c *Command) error { return errors.New("must not be called") }
+
+	c := &Command{
+		Name:   "app",
+		Before: fail,
+		Action: fail,
+		After:  fail,
+		Flags: []*Flag{
+			flag.New("verbose,v", false, "verbose output"),
+			flag.New("level", 0, "log level", flag.Local),
+			flag.New("debug", false, "debug", flag.Hidden),
+			{Name: "config", Action: func(f *Flag, arg string, args []string) ([]string, error) {
+				return nil, errors.New("must not be called")
+			}},
+		},
+		Commands: []*Command{{
+			Name:   "deploy",
+			Before: fail,
+			Action: fail,
+			Flags: []*Flag{
+				flag.New("to", "", "environment"),
+			},
+			Commands: []*Command{{
+				Name:   "rollback",
+				Action: fail,
+			}},
+		}, {
+			Name:   "describe",
+			Action: fail,
+		}, {
+			Name:   "secret",
+			Hidden: true,
+		}, CompleteCmd},
+		Stdout: &buf,
+	}
+
+	err := Run(c, nil, completeEnv(1, "app", "d"))
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy\t\tCommands\ndescribe\t\tCommands\n:keeporder\n", buf.String())
+
+	buf.Reset()
+
+	err = Run(c, nil, completeEnv(2, "app", "deploy", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, "rollback\t\tCommands\n:keeporder\n", buf.String())
+
+	buf.Reset()
+
+	err = Run(c, nil, completeEnv(6, "app", "--config", "app.yaml", "deploy", "--to", "prod", "--"))
+	assert.NoError(t, err)
+	assert.Equal(t, "--verbose\tverbose output\tFlags\n:keeporder\n", buf.String())
+	assert.Equal(t, "prod", c.Commands[0].Flag("to").Value)
+	assert.False(t, c.Flag("config").IsSet, "used flags are tracked aside")
+
+	buf.Reset()
+
+	err = Run(c, nil, completeEnv(1, "app", "_"))
+	assert.NoError(t, err)
+	assert.Equal(t, "_complete\tprint shell completion script\tCommands\n:keeporder\n", buf.String())
+}
+
+func TestCompleteFlagRules(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name: "app",
+		Flags: []*Flag{
+			flag.New("verbose,v", false, "verbose output"),
+			flag.New("level,l", 0, "log level", flag.Local),
+			flag.New("label", []string{}, "labels"),
+			flag.New("quiet", false, "quiet"),
+			flag.New("_debug", false, "debug", flag.Hidden),
+			flag.New("trace", false, "trace", flag.Hidden),
+		},
+		Commands: []*Command{{
+			Name: "run",
+			Args: Args{},
+			Flags: []*Flag{
+				flag.New("v", false, "run verbose"),
+				flag.New("format", "", "output format", flag.Hidden, func(f *Flag) {
+					f.Complete = FlagCompleter(CompleteList("json", "yaml"))
+				}),
+			},
+		}},
+		Stdout: &buf,
+	}
+
+	for _, tc := range []struct {
+		words []string
+		exp   string
+	}{
+		{[]string{"app", "-"}, "-v\tverbose output\tFlags\n-l\tlog level\tFlags\n--label\tlabels\tFlags\n--quiet\tquiet\tFlags\n:keeporder\n"},
+		{[]string{"app", "--"}, "--verbose\tverbose output\tFlags\n--level\tlog level\tFlags\n--label\tlabels\tFlags\n--quiet\tquiet\tFlags\n:keeporder\n"},
+		{[]string{"app", "-l"}, "-l\tlog level\tFlags\n:keeporder\n"},
+		{[]string{"app", "-le"}, ":keeporder\n"},
+		{[]string{"app", "--l"}, "--level\tlog level\tFlags\n--label\tlabels\tFlags\n:keeporder\n"},
+		{[]string{"app", "--_"}, "--_debug\tdebug\tFlags\n:keeporder\n"},
+		{[]string{"app", "--quiet", "--label=a", "--verbose", "--"}, "--level\tlog level\tFlags\n--label\tlabels\tFlags\n:keeporder\n"},
+		{[]string{"app", "run", "-"}, "-v\trun verbose\tFlags\n--verbose\tverbose output\tFlags\n--label\tlabels\tFlags\n--quiet\tquiet\tFlags\n:keeporder\n"},
+		{[]string{"app", "run", "--", "-"}, ":files\n"},
+		{[]string{"app", "run", "--format", ""}, "json\nyaml\n:\n"},
+		{[]string{"app", "run", "--", "--format", ""}, ":files\n"},
+		{[]string{"app", "run", "--", "--format="}, ":files\n"},
+	} {
+		buf.Reset()
+
+		err := Run(c, nil, completeEnv(len(tc.words)-1, tc.words...))
+		assert.NoError(t, err, "%q", tc.words)
+		assert.Equal(t, tc.exp, buf.String(), "%q", tc.words)
+	}
+}
+
+func TestCompleteValues(t *testing.T) {
+	var buf bytes.Buffer
+
+	dir := t.TempDir()
+
+	for _, n := range []string{"a.yaml", "b.json", "sub/c.yaml", ".hidden.yaml"} {
+		p := filepath.Join(dir, n)
+
+		err := os.MkdirAll(filepath.Dir(p), 0o755)
+		assert.NoError(t, err)
+
+		err = os.WriteFile(p, nil, 0o644)
+		assert.NoError(t, err)
+	}
+
+	c := &Command{
+		Name: "app",
+		Flags: []*Flag{
+			{Name: "config", Action: flag.ParseString, Complete: FlagCompleter(CompleteFiles(".yaml"))},
+			{Name: "format", Action: flag.ParseString, Complete: FlagCompleter(CompleteList("json", "yaml", "text"))},
+			{Name: "env", Action: flag.ParseStringSlice},
+			{Name: "default-env", Action: flag.ParseString, Complete: FlagCompleter(CompleteFlag("env"))},
+			{Name: "out", Action: flag.ParseString, Complete: FlagCompleter(CompleteDirs())},
+			FlagfileFlag,
+		},
+		Args:     Args{},
+		Complete: CompleteList("one", "two"),
+		Stdout:   &buf,
+	}
+
+	for _, tc := range []struct {
+		words []string
+		exp   string
+	}{
+		{[]string{"app", "--config", dir + "/"}, dir + "/a.yaml\n" + dir + "/sub/\n:nospace\n"},
+		{[]string{"app", "--out", dir + "/"}, dir + "/sub/\n:nospace\n"},
+		{[]string{"app", "--flagfile", dir + "/b"}, dir + "/b.json\n:\n"},
+		{[]string{"app", "--format=j"}, "--format=json\n:\n"},
+		{[]string{"app", "--format", "=", "y"}, "yaml\n:\n"},
+		{[]string{"app", "--format", "="}, "=json\n=yaml\n=text\n:\n"},
+		{[]string{"app", "--env", "dev", "--env=prod", "--default-env", ""}, "dev\nprod\n:\n"},
+		{[]string{"app", "--env", ""}, ":files\n"},
+		{[]string{"app", "--format", "json", "t"}, "two\n:keeporder\n"},
+	} {
+		buf.Reset()
+
+		err := Run(c, nil, completeEnv(len(tc.words)-1, tc.words...))
+		assert.NoError(t, err)
+
+		assert.Equal(t, tc.exp, buf.String(), "%q", tc.words)
+
+		c.Flags[2].Value = nil
+	}
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nikand.dev/go/cli/flag"
+)
+
+type (
+	// Completer returns positional arg or flag value completion candidates starting with prefix.
+	// A candidate may be followed by a tab and a description, see complete.Candidate.
+	// c is the partially parsed command.
+	Completer func(c *Command, prefix string) ([]string, error)
+)
+
+// FlagCompleter adapts Completer to be used as flag.Flag.Complete.
+func FlagCompleter(cm Completer) flag.Completer {
+	return func(f *Flag, prefix string) ([]string, error) {
+		c, _ := f.CurrentCommand.(*Command)
+
+		return cm(c, prefix)
+	}
+}
+
+// CompleteFiles completes file paths.
+// If extensions are given, like ".yaml", only files with them are offered.
+// Directories are always offered with a trailing slash to continue into them.
+// Hidden files are offered only if the prefix base starts with a dot.
+func CompleteFiles(exts ...string) Completer {
+	return func(c *Command, prefix string) ([]string, error) {
+		return completePaths(prefix, exts, false), nil
+	}
+}
+
+// CompleteDirs completes directory paths.
+func CompleteDirs() Completer {
+	return func(c *Command, prefix string) ([]string, error) {
+		return completePaths(prefix, nil, true), nil
+	}
+}
+
+// CompleteList completes one of the fixed values.
+func CompleteList(vals ...string) Completer {
+	return func(c *Command, prefix string) ([]string, error) {
+		return filterPrefix(vals, prefix), nil
+	}
+}
+
+// CompleteFlag completes values already given to another flag.
+// Slice values are offered element by element.
+func CompleteFlag(name string) Completer {
+	return func(c *Command, prefix string) ([]string, error) {
+		f := c.Flag(name)
+		if f == nil {
+			return nil, nil
+		}
+
+		var vals []string
+
+		switch v := f.Value.(type) {
+		case []string:
+			vals = v
+		default:
+			if s := flag.FormatValue(v); s != "" {
+				vals = []string{s}
+			}
+		}
+
+		return filterPrefix(vals, prefix), nil
+	}
+}
+
+func completePaths(prefix string, exts []string, dirsOnly bool) (res []string) {
+	dir, base := filepath.Split(prefix)
+
+	rd := dir
+	if rd == "" {
+		rd = "."
+	}
+
+	es, err := os.ReadDir(rd)
+	if err != nil {
+		return nil
+	}
+
+	for _, e := range es {
+		name := e.Name()
+
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		isDir := e.IsDir()
+
+		if e.Type()&os.ModeSymlink != 0 {
+			if st, err := os.Stat(filepath.Join(rd, name)); err == nil {
+				isDir = st.IsDir()
+			}
+		}
+
+		switch {
+		case isDir:
+			res = append(res, dir+name+"/")
+		case dirsOnly:
+		case len(exts) != 0 && !hasExt(name, exts):
+		default:
+			res = append(res, dir+name)
+		}
+	}
+
+	return res
+}
+
+func hasExt(name string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func filterPrefix(vals []string, prefix string) (res []string) {
+	for _, v := range vals {
+		if strings.HasPrefix(v, prefix) {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
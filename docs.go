@@ -0,0 +1,396 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// Docs generates Markdown or HTML documentation pages, one per command.
+	// Pages are built from HelpData, so they agree with the help output.
+	//
+	// Anchors are stable: the command anchor is ManName, app-deploy,
+	// and the flag anchor is the command anchor followed by -- and the flag main name, app-deploy--to.
+	// Page files are named by the command anchor: app-deploy.md.
+	Docs struct {
+		Format string // DocsMarkdown or DocsHTML, DocsMarkdown if empty
+		Hidden bool   // include hidden commands and flags
+	}
+)
+
+// Docs formats.
+const (
+	DocsMarkdown = "md"
+	DocsHTML     = "html"
+)
+
+// DocsFile is the command page file name.
+func (g *Docs) DocsFile(c *Command) string {
+	return ManName(c) + "." + g.format()
+}
+
+// WritePage writes the command page.
+func (g *Docs) WritePage(w io.Writer, c *Command) error {
+	c.setup()
+
+	d := NewHelpData(c, g.Hidden)
+
+	var b bytes.Buffer
+
+	if g.format() == DocsHTML {
+		g.html(&b, c, d)
+	} else {
+		g.markdown(&b, c, d)
+	}
+
+	_, err := b.WriteTo(w)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
+
+// WriteDir writes a page per command in the subtree to the dir.
+// Written file paths are returned.
+func (g *Docs) WriteDir(dir string, c *Command) (files []string, err error) {
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, wrap(err, "mkdir")
+	}
+
+	err = g.walk(c, func(cur *Command) error {
+		var b bytes.Buffer
+
+		err := g.WritePage(&b, cur)
+		if err != nil {
+			return wrap(err, "%v", ManName(cur))
+		}
+
+		name := filepath.Join(dir, g.DocsFile(cur))
+
+		err = os.WriteFile(name, b.Bytes(), 0o644)
+		if err != nil {
+			return wrap(err, "write file")
+		}
+
+		files = append(files, name)
+
+		return nil
+	})
+
+	return files, err
+}
+
+func (g *Docs) walk(c *Command, visit func(c *Command) error) error {
+	c.setup()
+
+	err := visit(c)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range c.Commands {
+		if sub == nil || sub.Name == "" || sub.Hidden && !g.Hidden {
+			continue
+		}
+
+		err = g.walk(sub, visit)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Docs) markdown(b *bytes.Buffer, c *Command, d *HelpData) {
+	fmt.Fprintf(b, "<a id=\"%s\"></a>\n\n# %s\n\n", ManName(c), d.Path)
+
+	if d.Description != "" {
+		fmt.Fprintf(b, "%s\n\n", d.Description)
+	}
+
+	fmt.Fprintf(b, "```\n%s %s\n```\n\n", d.Path, d.Usage)
+
+	if d.Help != "" {
+		fmt.Fprintf(b, "%s\n\n", strings.TrimRight(d.Help, "\n"))
+	}
+
+	if c.Parent != nil {
+		fmt.Fprintf(b, "Parent command: [%s](%s#%s)\n\n", strings.Join(FullName(c.Parent), " "), g.DocsFile(c.Parent), ManName(c.Parent))
+	}
+
+	for _, gr := range d.CommandGroups {
+		fmt.Fprintf(b, "## %s\n\n", docsGroupName(gr.Name, "Subcommands"))
+
+		for _, it := range gr.Items {
+			if it.Command == nil {
+				continue
+			}
+
+			_ = g.walk(it.Command, func(cur *Command) error {
+				depth := len(FullName(cur)) - len(FullName(c)) - 1
+
+				fmt.Fprintf(b, "%s- [%s](%s#%s)", strings.Repeat("  ", depth), strings.Join(FullName(cur), " "), g.DocsFile(cur), ManName(cur))
+
+				if cur.Description != "" {
+					fmt.Fprintf(b, " - %s", mdCell(cur.Description))
+				}
+
+				fmt.Fprintf(b, "\n")
+
+				return nil
+			})
+		}
+
+		fmt.Fprintf(b, "\n")
+	}
+
+	for _, fs := range d.Flags {
+		for _, gr := range fs.Groups {
+			fmt.Fprintf(b, "## %s\n\n", docsFlagsHeading(fs, gr.Name))
+
+			fmt.Fprintf(b, "| Flag | Description | Default | Env | Required |\n")
+			fmt.Fprintf(b, "| --- | --- | --- | --- | --- |\n")
+
+			for _, it := range gr.Items {
+				if it.Flag == nil {
+					continue
+				}
+
+				req := ""
+				if it.Required {
+					req = "yes"
+				}
+
+				fmt.Fprintf(b, "| <a id=\"%s\"></a>%s | %s | %s | %s | %s |\n",
+					docsFlagAnchor(fs.Command, it), mdCode(docsFlagName(it)), mdCell(it.Description),
+					mdCode(it.Default), mdCode(FlagEnv(c, it.Flag)), req)
+			}
+
+			fmt.Fprintf(b, "\n")
+		}
+	}
+
+	if len(d.Examples) != 0 {
+		fmt.Fprintf(b, "## Examples\n\n")
+
+		for _, e := range d.Examples {
+			if e.Description != "" {
+				fmt.Fprintf(b, "%s\n\n", e.Description)
+			}
+
+			fmt.Fprintf(b, "```\n$ %s\n", e.Command)
+
+			if e.Output != "" {
+				fmt.Fprintf(b, "%s\n", e.Output)
+			}
+
+			fmt.Fprintf(b, "```\n\n")
+		}
+	}
+
+	b.Truncate(len(bytes.TrimRight(b.Bytes(), "\n")) + 1)
+}
+
+func (g *Docs) html(b *bytes.Buffer, c *Command, d *HelpData) {
+	esc := html.EscapeString
+
+	fmt.Fprintf(b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", esc(d.Path))
+	fmt.Fprintf(b, "<h1 id=\"%s\">%s</h1>\n", ManName(c), esc(d.Path))
+
+	if d.Description != "" {
+		fmt.Fprintf(b, "<p>%s</p>\n", esc(d.Description))
+	}
+
+	fmt.Fprintf(b, "<pre>%s %s</pre>\n", esc(d.Path), esc(d.Usage))
+
+	if d.Help != "" {
+		for _, p := range strings.Split(strings.TrimRight(d.Help, "\n"), "\n\n") {
+			fmt.Fprintf(b, "<p>%s</p>\n", esc(p))
+		}
+	}
+
+	if c.Parent != nil {
+		fmt.Fprintf(b, "<p>Parent command: <a href=\"%s#%s\">%s</a></p>\n", g.DocsFile(c.Parent), ManName(c.Parent), esc(strings.Join(FullName(c.Parent), " ")))
+	}
+
+	var list, item func(cur *Command)
+	list = func(cur *Command) {
+		fmt.Fprintf(b, "<ul>\n")
+
+		for _, sub := range cur.Commands {
+			if sub == nil || sub.Name == "" || sub.Hidden && !g.Hidden {
+				continue
+			}
+
+			item(sub)
+		}
+
+		fmt.Fprintf(b, "</ul>\n")
+	}
+
+	item = func(sub *Command) {
+		sub.setup()
+
+		fmt.Fprintf(b, "<li><a href=\"%s#%s\">%s</a>", g.DocsFile(sub), ManName(sub), esc(strings.Join(FullName(sub), " ")))
+
+		if sub.Description != "" {
+			fmt.Fprintf(b, " - %s", esc(sub.Description))
+		}
+
+		if hasVisibleCommands(sub, g.Hidden) {
+			fmt.Fprintf(b, "\n")
+			list(sub)
+		}
+
+		fmt.Fprintf(b, "</li>\n")
+	}
+
+	for _, gr := range d.CommandGroups {
+		fmt.Fprintf(b, "<h2>%s</h2>\n<ul>\n", esc(docsGroupName(gr.Name, "Subcommands")))
+
+		for _, it := range gr.Items {
+			if it.Command != nil {
+				item(it.Command)
+			}
+		}
+
+		fmt.Fprintf(b, "</ul>\n")
+	}
+
+	for _, fs := range d.Flags {
+		for _, gr := range fs.Groups {
+			fmt.Fprintf(b, "<h2>%s</h2>\n", esc(docsFlagsHeading(fs, gr.Name)))
+
+			fmt.Fprintf(b, "<table>\n<tr><th>Flag</th><th>Description</th><th>Default</th><th>Env</th><th>Required</th></tr>\n")
+
+			for _, it := range gr.Items {
+				if it.Flag == nil {
+					continue
+				}
+
+				req := ""
+				if it.Required {
+					req = "yes"
+				}
+
+				fmt.Fprintf(b, "<tr id=\"%s\"><td><code>%s</code></td><td>%s</td><td><code>%s</code></td><td><code>%s</code></td><td>%s</td></tr>\n",
+					docsFlagAnchor(fs.Command, it), esc(docsFlagName(it)), strings.ReplaceAll(esc(it.Description), "\n", "<br>"),
+					esc(it.Default), esc(FlagEnv(c, it.Flag)), req)
+			}
+
+			fmt.Fprintf(b, "</table>\n")
+		}
+	}
+
+	if len(d.Examples) != 0 {
+		fmt.Fprintf(b, "<h2>Examples</h2>\n")
+
+		for _, e := range d.Examples {
+			if e.Description != "" {
+				fmt.Fprintf(b, "<p>%s</p>\n", esc(e.Description))
+			}
+
+			fmt.Fprintf(b, "<pre>$ %s", esc(e.Command))
+
+			if e.Output != "" {
+				fmt.Fprintf(b, "\n%s", esc(e.Output))
+			}
+
+			fmt.Fprintf(b, "</pre>\n")
+		}
+	}
+
+	fmt.Fprintf(b, "</body>\n</html>\n")
+}
+
+func (g *Docs) format() string {
+	if g.Format == "" {
+		return DocsMarkdown
+	}
+
+	return g.Format
+}
+
+func hasVisibleCommands(c *Command, hidden bool) bool {
+	for _, sub := range c.Commands {
+		if sub != nil && sub.Name != "" && (!sub.Hidden || hidden) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// docsGroupName is the group section title, def for ungrouped items.
+func docsGroupName(name, def string) string {
+	if name == "" {
+		return def
+	}
+
+	return name
+}
+
+// docsFlagsHeading is the flags group section title as in help.
+func docsFlagsHeading(fs HelpFlags, group string) string {
+	switch {
+	case fs.Parent && group != "":
+		return group + " (inherited from " + fs.Name + ")"
+	case fs.Parent:
+		return "Flags inherited from " + fs.Name
+	default:
+		return docsGroupName(group, "Flags")
+	}
+}
+
+// docsFlagAnchor is the owning command anchor followed by -- and the flag main name.
+func docsFlagAnchor(c *Command, it HelpItem) string {
+	return ManName(c) + "--" + it.Flag.MainName()
+}
+
+// docsFlagName formats flag aliases with dashes and the value placeholder: --to, -t=string.
+func docsFlagName(it HelpItem) string {
+	var b strings.Builder
+
+	for i, n := range strings.Split(it.Name, ",") {
+		if i != 0 {
+			b.WriteString(", ")
+		}
+
+		if len(n) == 1 {
+			b.WriteString("-")
+		} else {
+			b.WriteString("--")
+		}
+
+		b.WriteString(n)
+	}
+
+	b.WriteString(it.Usage)
+
+	return b.String()
+}
+
+// mdCell escapes text for a Markdown table cell.
+func mdCell(s string) string {
+	s = strings.TrimRight(s, "\n")
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\n", "<br>")
+
+	return s
+}
+
+func mdCode(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "`" + mdCell(s) + "`"
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikandfor/assert"
+	"nikand.dev/go/cli/flag"
+)
+
+func TestDocsMarkdown(t *testing.T) {
+	c := manTestCommand()
+	c.setup()
+
+	var b bytes.Buffer
+
+	err := (&Docs{}).WritePage(&b, c.Commands[0])
+	assert.NoError(t, err)
+
+	assert.Equal(t, "<a id=\"app-deploy\"></a>\n\n# app deploy\n\ndeploy the app\n\n"+
+		"```\napp deploy [flags] <version>\n```\n\n"+
+		"Parent command: [app](app.md#app)\n\n"+
+		"## Subcommands\n\n"+
+		"- [app deploy rollback](app-deploy-rollback.md#app-deploy-rollback) - rollback\n\n"+
+		"## Flags\n\n"+
+		"| Flag | Description | Default | Env | Required |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| <a id=\"app-deploy--to\"></a>`--to=string` | environment | `prod` | `APP_TO` | yes |\n\n"+
+		"## Flags inherited from app\n\n"+
+		"| Flag | Description | Default | Env | Required |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| <a id=\"app--verbose\"></a>`--verbose, -v` | verbose output | `false` | `APP_VERBOSE` |  |\n\n"+
+		"## Examples\n\n"+
+		"deploy v1\n\n"+
+		"```\n$ app deploy v1\ndeployed\n```\n", b.String())
+}
+
+func TestDocsDir(t *testing.T) {
+	c := manTestCommand()
+	dir := t.TempDir()
+
+	files, err := (&Docs{Format: DocsHTML}).WriteDir(dir, c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "app.html"),
+		filepath.Join(dir, "app-deploy.html"),
+		filepath.Join(dir, "app-deploy-rollback.html"),
+	}, files)
+
+	data, err := os.ReadFile(filepath.Join(dir, "app.html"))
+	assert.NoError(t, err)
+
+	s := string(data)
+
+	assert.True(t, strings.Contains(s, `<h1 id="app">app</h1>`), "%s", s)
+	assert.True(t, strings.Contains(s, "<ul>\n<li><a href=\"app-deploy.html#app-deploy\">app deploy</a> - deploy the app\n"+
+		"<ul>\n<li><a href=\"app-deploy-rollback.html#app-deploy-rollback\">app deploy rollback</a> - rollback</li>\n</ul>\n</li>\n</ul>\n"), "%s", s)
+	assert.True(t, strings.Contains(s, `<tr id="app--level"><td><code>--level=int</code></td><td>log level</td><td><code>3</code></td><td><code>APP_LEVEL</code></td><td></td></tr>`), "%s", s)
+	assert.True(t, !strings.Contains(s, "secret") && !strings.Contains(s, "_man"), "%s", s)
+}
+
+func TestDocsGroups(t *testing.T) {
+	c := groupsTestCommand()
+	c.setup()
+
+	var b bytes.Buffer
+
+	err := (&Docs{}).WritePage(&b, c)
+	assert.NoError(t, err)
+
+	s := b.String()
+
+	assert.True(t, strings.Contains(s, "## Management\n\n- [app drain](app-drain.md#app-drain) - drain a node\n\n"+
+		"## Subcommands\n\n- [app version](app-version.md#app-version) - print version\n\n"+
+		"## Basic\n\n- [app get](app-get.md#app-get) - get an object\n- [app create](app-create.md#app-create) - create an object\n\n"), "%s", s)
+	assert.True(t, strings.Contains(s, "## Flags\n\n| Flag |"), "%s", s)
+	assert.True(t, strings.Contains(s, "## Network\n\n| Flag |"), "%s", s)
+
+	b.Reset()
+
+	err = (&Docs{Format: DocsHTML}).WritePage(&b, c.Commands[0])
+	assert.NoError(t, err)
+
+	s = b.String()
+
+	assert.True(t, strings.Contains(s, "<h2>Flags inherited from app</h2>\n<table>\n<tr><th>Flag</th><th>Description</th><th>Default</th><th>Env</th><th>Required</th></tr>\n"+
+		"<tr id=\"app--verbose\">"), "%s", s)
+	assert.True(t, strings.Contains(s, "<h2>Network (inherited from app)</h2>\n<table>\n<tr><th>Flag</th><th>Description</th><th>Default</th><th>Env</th><th>Required</th></tr>\n"+
+		"<tr id=\"app--host\">"), "%s", s)
+}
+
+func groupsTestCommand() *Command {
+	return &Command{
+		Name:   "app",
+		Groups: []string{"Management", ""},
+		Flags: []*Flag{
+			{Name: "host", Group: "Network", Description: "host to connect", Action: flag.ParseString},
+			{Name: "verbose", Description: "verbose output", Action: flag.ParseBool},
+			{Name: "port", Group: "Network", Description: "port to connect", Action: flag.ParseInt},
+		},
+		Commands: []*Command{{
+			Name:        "get",
+			Group:       "Basic",
+			Description: "get an object",
+		}, {
+			Name:        "version",
+			Description: "print version",
+		}, {
+			Name:        "create",
+			Group:       "Basic",
+			Description: "create an object",
+		}, {
+			Name:        "drain",
+			Group:       "Management",
+			Description: "drain a node",
+		}},
+	}
+}
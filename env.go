@@ -4,15 +4,22 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"strconv"
 	"strings"
 
 	"nikand.dev/go/cli/flag"
 )
 
+// EnvfileFlag loads env vars from the file: KEY=value or KEY value lines,
+// optionally prefixed with export. # comments are skipped.
+// Quotes around values are removed: double quoted values are unescaped as Go strings,
+// single quoted ones are taken as is. Values with unmatched or invalid quotes are kept untouched.
 var EnvfileFlag = &Flag{
 	Name:        "envfile",
+	Usage:       "=file",
 	Description: "load env variables from file",
 	Action:      envfile,
+	Complete:    FlagCompleter(CompleteFiles()),
 }
 
 func (c *Command) Getenv(key string) (val string) {
@@ -21,7 +28,11 @@ func (c *Command) Getenv(key string) (val string) {
 }
 
 func (c *Command) LookupEnv(key string) (string, bool) {
-	for _, e := range c.Env {
+	return lookupEnv(c.Env, key)
+}
+
+func lookupEnv(env []string, key string) (string, bool) {
+	for _, e := range env {
 		p := strings.IndexAny(e, "= ")
 		if p == -1 {
 			p = len(e)
@@ -68,7 +79,7 @@ func envfile(f *Flag, arg string, args []string) (_ []string, err error) {
 		e = strings.TrimPrefix(e, "export ")
 		e = strings.TrimSpace(e)
 
-		env = append(env, e)
+		env = append(env, unquoteEnv(e))
 	}
 
 	if err = r.Err(); err != nil {
@@ -85,6 +96,36 @@ func envfile(f *Flag, arg string, args []string) (_ []string, err error) {
 	return args, nil
 }
 
+// unquoteEnv removes double or single quotes around the value of KEY=value env var line.
+// Double quoted values are unescaped as Go strings.
+func unquoteEnv(e string) string {
+	p := strings.IndexAny(e, "= ")
+	if p == -1 {
+		return e
+	}
+
+	v := strings.TrimSpace(e[p+1:])
+	if len(v) < 2 || v[0] != v[len(v)-1] {
+		return e
+	}
+
+	switch v[0] {
+	case '"':
+		u, err := strconv.Unquote(v)
+		if err != nil {
+			return e
+		}
+
+		v = u
+	case '\'':
+		v = v[1 : len(v)-1]
+	default:
+		return e
+	}
+
+	return e[:p+1] + v
+}
+
 func DefaultParseEnv(c *Command, env []string) (rest []string, err error) {
 	prefix := GetEnvPrefix(c)
 	if prefix == "" {
@@ -113,6 +154,9 @@ func DefaultParseEnv(c *Command, env []string) (rest []string, err error) {
 
 			continue
 		}
+		if f := c.Flag(flagName(e)); err != nil && f != nil && f.Secret && p != -1 {
+			err = flag.RedactError(err, e[p+1:])
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -128,3 +172,14 @@ func varname(s string) string {
 
 	return s
 }
+
+// FlagEnv returns the env var name which sets the flag for the command.
+// It's empty if the command has no EnvPrefix.
+func FlagEnv(c *Command, f *Flag) string {
+	prefix := GetEnvPrefix(c)
+	if prefix == "" {
+		return ""
+	}
+
+	return prefix + strings.ToUpper(strings.ReplaceAll(f.MainName(), "-", "_"))
+}
@@ -1,36 +1,191 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+
+	"nikand.dev/go/cli/flag"
+)
+
+type (
+	// EnvVar is an env var the command accepts to set a flag.
+	EnvVar struct {
+		Name        string `json:"name"`
+		Flag        string `json:"flag"`
+		Description string `json:"description,omitempty"`
+		Default     string `json:"default,omitempty"`
+		Value       string `json:"value,omitempty"` // current flag value
+		Set         bool   `json:"set"`             // set in the environment
+		Secret      bool   `json:"secret,omitempty"`
+	}
 )
 
 var EnvCmd = &Command{
-	Name:   "env",
-	Action: envAction,
+	Name:         "env",
+	Description:  "list env vars the command accepts",
+	Action:       envAction,
+	Flags:        envCmdFlags(),
+	builtinFlags: envCmdFlags,
+}
+
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+func envCmdFlags() []*Flag {
+	return []*Flag{
+		flag.New("format,f", "plain", "output format: plain, export, dotenv or json", func(f *Flag) {
+			f.Complete = FlagCompleter(CompleteList("plain", "export", "dotenv", "json"))
+		}),
+		flag.New("hidden", false, "include hidden flags"),
+	}
 }
 
 func envAction(c *Command) (err error) {
-	var pref []string
+	cmd := c
+	if c.Parent != nil {
+		cmd = c.Parent
+	}
 
-	for q := c; q != nil; q = q.Parent {
-		if q.EnvPrefix != "" {
-			pref = append(pref, q.EnvPrefix)
+	vars := EnvVars(cmd, c.Bool("hidden"))
+
+	var b strings.Builder
+
+	switch format := c.String("format"); format {
+	case "plain":
+		w := 0
+		for _, v := range vars {
+			if l := len(v.Name) + 1 + len(v.Value); l > w {
+				w = l
+			}
+		}
+
+		for _, v := range vars {
+			var notes []string
+
+			if v.Set {
+				notes = append(notes, "set")
+			}
+
+			if v.Default != "" {
+				notes = append(notes, "default "+v.Default)
+			}
+
+			desc := v.Description
+
+			if len(notes) != 0 {
+				if desc != "" {
+					desc += " "
+				}
+
+				desc += "(" + strings.Join(notes, ", ") + ")"
+			}
+
+			l := fmt.Sprintf("%-*s  %s", w, v.Name+"="+v.Value, desc)
+
+			b.WriteString(strings.TrimRight(l, " "))
+			b.WriteString("\n")
 		}
+	case "export", "dotenv":
+		for _, v := range vars {
+			if v.Description != "" {
+				fmt.Fprintf(&b, "# %s\n", v.Description)
+			}
+
+			if !v.Set || v.Secret {
+				b.WriteString("# ")
+			}
+
+			if format == "export" {
+				fmt.Fprintf(&b, "export %s=%s\n", v.Name, shellQuote(v.Value))
+			} else {
+				fmt.Fprintf(&b, "%s=%s\n", v.Name, dotenvQuote(v.Value))
+			}
+		}
+	case "json":
+		if vars == nil {
+			vars = []EnvVar{}
+		}
+
+		data, err := json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			return wrap(err, "marshal")
+		}
+
+		b.Write(data)
+		b.WriteString("\n")
+	default:
+		return wrap(ErrUnsupportedFormat, "%v", format)
 	}
 
-	if len(pref) == 0 {
-		return nil
+	_, err = c.Stdout.Write([]byte(b.String()))
+	if err != nil {
+		return wrap(err, "write")
 	}
 
-	for _, e := range c.OSEnv {
-		for _, p := range pref {
-			if strings.HasPrefix(e, p) {
-				fmt.Fprintf(c.Stdout, "%v\n", e)
-				break
+	return nil
+}
+
+// EnvVars returns env vars the command accepts: one per flag in scope of each command from the root down to c
+// which has EnvPrefix set or inherited.
+// Set and Value are taken from the root OSEnv. Value is the current flag value if the var is not set.
+// Default is the flag DefaultText or its value before parsing, see flag.Flag.DefaultString.
+// Secret values are redacted.
+func EnvVars(c *Command, hidden bool) (vars []EnvVar) {
+	var path []*Command
+
+	for q := c; q != nil; q = q.Parent {
+		path = append(path, q)
+	}
+
+	root := path[len(path)-1]
+	seen := map[string]bool{}
+
+	for i := len(path) - 1; i >= 0; i-- {
+		q := path[i]
+
+		for _, f := range scopeFlags(q) {
+			if f.Hidden && !hidden {
+				continue
 			}
+
+			name := FlagEnv(q, f)
+			if name == "" || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+
+			v := EnvVar{
+				Name:        name,
+				Flag:        f.MainName(),
+				Description: f.Description,
+				Default:     f.DefaultString(),
+				Secret:      f.Secret,
+			}
+
+			v.Value, v.Set = lookupEnv(root.OSEnv, name)
+			if !v.Set {
+				v.Value = flag.FormatValue(f.Value)
+			}
+
+			if f.Secret && v.Value != "" {
+				v.Value = flag.Redacted
+			}
+
+			vars = append(vars, v)
 		}
 	}
 
-	return nil
+	return vars
+}
+
+// dotenvQuote quotes the value if needed, so it's read back as is by EnvfileFlag.
+func dotenvQuote(v string) string {
+	if v == "" || !strings.ContainsAny(v, " \t\r\n\"'#\\$") {
+		return v
+	}
+
+	return strconv.Quote(v)
 }
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/nikandfor/assert"
@@ -8,6 +9,8 @@ import (
 )
 
 func TestEnvfile(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+
 	readFile = func(n string) ([]byte, error) {
 		assert.Equal(t, ".env", n)
 
@@ -15,6 +18,12 @@ func TestEnvfile(t *testing.T) {
 		PREF_F2 2
 		# PREF_F3=a
 		PREF_F4 abc def
+		PREF_Q1="a \"b\"\tc"
+		export PREF_Q2='d \e'
+		PREF_Q3 "f g"
+		PREF_Q4="unterminated
+		PREF_Q5="bad \q"
+		PREF_Q6='mixed"
 		NOT_PREF_F3=3`), nil
 	}
 
@@ -31,6 +40,12 @@ func TestEnvfile(t *testing.T) {
 			flag.New("f4", "", ""),
 			flag.New("f5", "", ""),
 			flag.New("f6", 0, ""),
+			flag.New("q1", "", ""),
+			flag.New("q2", "", ""),
+			flag.New("q3", "", ""),
+			flag.New("q4", "", ""),
+			flag.New("q5", "", ""),
+			flag.New("q6", "", ""),
 			EnvfileFlag,
 		},
 		EnvPrefix: "PREF_",
@@ -58,5 +73,164 @@ func TestEnvfile(t *testing.T) {
 	assert.Equal(t, "4", c.Flag("f5").Value)
 	assert.Equal(t, 9, c.Flag("f6").Value)
 
+	assert.Equal(t, "a \"b\"\tc", c.Flag("q1").Value, "double quotes are unescaped")
+	assert.Equal(t, `d \e`, c.Flag("q2").Value, "single quotes are taken as is")
+	assert.Equal(t, "f g", c.Flag("q3").Value)
+	assert.Equal(t, `"unterminated`, c.Flag("q4").Value)
+	assert.Equal(t, `"bad \q"`, c.Flag("q5").Value)
+	assert.Equal(t, `'mixed"`, c.Flag("q6").Value)
+
 	assert.Equal(t, []string{"NOT_PREF_F3=3"}, c.Env)
 }
+
+func TestEnvCmd(t *testing.T) {
+	var buf bytes.Buffer
+
+	defer func() { EnvCmd.Stdout = nil }()
+
+	app := func() *Command {
+		return &Command{
+			Name:      "app",
+			EnvPrefix: "APP_",
+			Flags: []*Flag{
+				flag.New("token", "", "api token", flag.Secret),
+				flag.New("user", "root", "user name"),
+				flag.New("log-level,l", 1, "log level"),
+				flag.New("debug", false, "debug", flag.Hidden),
+			},
+			Commands: []*Command{
+				EnvCmd,
+			},
+			Stdout: &buf,
+		}
+	}
+
+	env := []string{"APP_TOKEN=qwerty", "APP_USER=admin user", "OTHER=1"}
+
+	run := func(args ...string) string {
+		buf.Reset()
+
+		err := Run(app(), append([]string{"app", "env"}, args...), env)
+		assert.NoError(t, err)
+
+		return buf.String()
+	}
+
+	plain := `APP_TOKEN=******     api token (set)
+APP_USER=admin user  user name (set, default root)
+APP_LOG_LEVEL=1      log level (default 1)
+`
+
+	assert.Equal(t, plain, run())
+
+	assert.Equal(t, `# api token
+# export APP_TOKEN='******'
+# user name
+export APP_USER='admin user'
+# log level
+# export APP_LOG_LEVEL=1
+`, run("--format=export"))
+
+	dotenv := run("-f", "dotenv")
+
+	assert.Equal(t, `# api token
+# APP_TOKEN=******
+# user name
+APP_USER="admin user"
+# log level
+# APP_LOG_LEVEL=1
+`, dotenv)
+
+	assert.Equal(t, `[
+  {
+    "name": "APP_TOKEN",
+    "flag": "token",
+    "description": "api token",
+    "value": "******",
+    "set": true,
+    "secret": true
+  },
+  {
+    "name": "APP_USER",
+    "flag": "user",
+    "description": "user name",
+    "default": "root",
+    "value": "admin user",
+    "set": true
+  },
+  {
+    "name": "APP_LOG_LEVEL",
+    "flag": "log-level",
+    "description": "log level",
+    "default": "1",
+    "value": "1",
+    "set": false
+  }
+]
+`, run("--format", "json"))
+
+	buf.Reset()
+
+	err := Run(app(), []string{"app", "env", "--format=yaml"}, env)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	assert.Equal(t, plain, run(), "env command flags are not kept between runs")
+}
+
+func TestEnvVarsSubcommand(t *testing.T) {
+	c := &Command{
+		Name:      "app",
+		EnvPrefix: "APP_",
+		Flags: []*Flag{
+			flag.New("user", "", "user name"),
+			flag.New("level", 0, "log level", flag.Local),
+		},
+		Commands: []*Command{{
+			Name:      "deploy",
+			EnvPrefix: "DEPLOY_",
+			Flags: []*Flag{
+				flag.New("to", "", "environment"),
+			},
+		}},
+	}
+
+	c.Commands[0].Parent = c
+
+	var names []string
+
+	for _, v := range EnvVars(c.Commands[0], false) {
+		names = append(names, v.Name)
+	}
+
+	assert.Equal(t, []string{"APP_USER", "APP_LEVEL", "DEPLOY_TO", "DEPLOY_USER"}, names)
+}
+
+func TestEnvCmdDotenvRoundTrip(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+	defer func() { EnvCmd.Stdout = nil }()
+
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name:      "app",
+		EnvPrefix: "APP_",
+		Action:    func(*Command) error { return nil },
+		Flags: []*Flag{
+			flag.New("user", "", "user name"),
+			flag.New("motd", "", "message"),
+			EnvfileFlag,
+		},
+		Commands: []*Command{EnvCmd},
+		Stdout:   &buf,
+	}
+
+	err := Run(c, []string{"app", "env", "--format=dotenv"}, []string{"APP_USER=admin user", `APP_MOTD=say "hi" # \o/`})
+	assert.NoError(t, err)
+
+	readFile = func(n string) ([]byte, error) { return buf.Bytes(), nil }
+
+	err = Run(c, []string{"app", "--envfile=.env"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin user", c.Flag("user").Value)
+	assert.Equal(t, `say "hi" # \o/`, c.Flag("motd").Value)
+}
@@ -0,0 +1,17 @@
+package cli
+
+type (
+	// Example is a command usage example.
+	// Examples are shown in help and generated docs,
+	// and can be checked by clitest.Examples.
+	Example struct {
+		Command     string // command line starting with the root command name, flagfile quoting rules apply
+		Description string
+		Output      string // expected output, optional
+	}
+)
+
+// Args splits the example command line into args.
+func (e Example) Args() ([]string, error) {
+	return splitArgs([]byte(e.Command), false)
+}
@@ -15,9 +15,10 @@ import (
 	"nikand.dev/go/cli/flag"
 )
 
+// set by ldflags, taken from the go build info if empty
 var (
-	version = "dev"
-	commit  = "dev"
+	version string
+	commit  string
 	date    string
 )
 
@@ -47,7 +48,9 @@ Greetings are golang 'text/template's with Command argument. Example greetings:
 
 			cli.EnvfileFlag,
 			cli.FlagfileFlag,
+			cli.NoInputFlag,
 			cli.HelpFlag,
+			cli.VersionFlag(version, commit, date),
 		},
 		Commands: []*cli.Command{
 			{
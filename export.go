@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type (
+	// JSONDoc is the command tree exported for tools like IDE plugins.
+	// The format is described by JSONSchema.
+	// Fields are only added in the same Version, incompatible changes increment it.
+	JSONDoc struct {
+		Version int          `json:"version"`
+		Command *JSONCommand `json:"command"`
+	}
+
+	JSONCommand struct {
+		Name        string         `json:"name"`
+		Aliases     []string       `json:"aliases,omitempty"`
+		Path        []string       `json:"path"` // full name starting from the root
+		Usage       string         `json:"usage"`
+		Description string         `json:"description,omitempty"`
+		Help        string         `json:"help,omitempty"`
+		Group       string         `json:"group,omitempty"`
+		Hidden      bool           `json:"hidden,omitempty"`
+		Args        bool           `json:"args"`                 // positional args are accepted
+		ArgsUsage   string         `json:"args_usage,omitempty"` // positional args part of the usage
+		Flags       []*JSONFlag    `json:"flags,omitempty"`
+		Commands    []*JSONCommand `json:"commands,omitempty"`
+		Examples    []*JSONExample `json:"examples,omitempty"`
+	}
+
+	JSONFlag struct {
+		Name        string   `json:"name"`
+		Aliases     []string `json:"aliases,omitempty"`
+		Type        string   `json:"type,omitempty"` // see flag.Flag.TypeName
+		Usage       string   `json:"usage,omitempty"`
+		Description string   `json:"description,omitempty"`
+		Help        string   `json:"help,omitempty"`
+		Group       string   `json:"group,omitempty"`
+		Default     string   `json:"default,omitempty"`
+		Env         string   `json:"env,omitempty"`
+		Hidden      bool     `json:"hidden,omitempty"`
+		Local       bool     `json:"local,omitempty"` // not inherited by subcommands
+		Required    bool     `json:"required,omitempty"`
+		Secret      bool     `json:"secret,omitempty"`
+	}
+
+	JSONExample struct {
+		Command     string `json:"command"`
+		Description string `json:"description,omitempty"`
+		Output      string `json:"output,omitempty"`
+	}
+)
+
+// JSONVersion is the current JSONDoc version.
+const JSONVersion = 1
+
+// JSONSchema is JSON Schema of JSONDoc.
+const JSONSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "$id": "https://nikand.dev/go/cli/schema/v1.json",
+  "title": "cli command tree",
+  "type": "object",
+  "required": ["version", "command"],
+  "properties": {
+    "version": { "const": 1 },
+    "command": { "$ref": "#/$defs/command" }
+  },
+  "$defs": {
+    "command": {
+      "type": "object",
+      "required": ["name", "path", "usage", "args"],
+      "properties": {
+        "name": { "type": "string", "description": "main name" },
+        "aliases": { "type": "array", "items": { "type": "string" } },
+        "path": { "type": "array", "items": { "type": "string" }, "description": "full name starting from the root" },
+        "usage": { "type": "string" },
+        "description": { "type": "string" },
+        "help": { "type": "string" },
+        "group": { "type": "string" },
+        "hidden": { "type": "boolean" },
+        "args": { "type": "boolean", "description": "positional args are accepted" },
+        "args_usage": { "type": "string", "description": "positional args part of the usage" },
+        "flags": { "type": "array", "items": { "$ref": "#/$defs/flag" } },
+        "commands": { "type": "array", "items": { "$ref": "#/$defs/command" } },
+        "examples": { "type": "array", "items": { "$ref": "#/$defs/example" } }
+      }
+    },
+    "flag": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": { "type": "string", "description": "main name" },
+        "aliases": { "type": "array", "items": { "type": "string" } },
+        "type": { "type": "string", "description": "value type: bool, int, uint, float, duration, string, string..., or a custom one" },
+        "usage": { "type": "string", "description": "value placeholder as shown in help" },
+        "description": { "type": "string" },
+        "help": { "type": "string" },
+        "group": { "type": "string" },
+        "default": { "type": "string", "description": "default value as passed in args" },
+        "env": { "type": "string", "description": "env var name setting the flag" },
+        "hidden": { "type": "boolean" },
+        "local": { "type": "boolean", "description": "not inherited by subcommands" },
+        "required": { "type": "boolean" },
+        "secret": { "type": "boolean" }
+      }
+    },
+    "example": {
+      "type": "object",
+      "required": ["command"],
+      "properties": {
+        "command": { "type": "string" },
+        "description": { "type": "string" },
+        "output": { "type": "string" }
+      }
+    }
+  }
+}
+`
+
+// ExportJSON exports the command subtree.
+// Hidden commands and flags are included if hidden is true.
+func ExportJSON(c *Command, hidden bool) *JSONDoc {
+	return &JSONDoc{
+		Version: JSONVersion,
+		Command: exportCommand(c, hidden),
+	}
+}
+
+func exportCommand(c *Command, hidden bool) *JSONCommand {
+	c.setup()
+
+	names := strings.Split(c.Name, ",")
+
+	d := NewHelpData(c, hidden)
+
+	r := &JSONCommand{
+		Name:        names[0],
+		Aliases:     names[1:],
+		Path:        FullName(c),
+		Usage:       d.Usage,
+		Description: c.Description,
+		Help:        c.Help,
+		Group:       c.Group,
+		Hidden:      c.Hidden,
+		Args:        c.Args != nil,
+		ArgsUsage:   exportArgsUsage(c, d.Usage),
+	}
+
+	for _, f := range c.Flags {
+		if f == nil || f.Name == "" || f.Hidden && !hidden {
+			continue
+		}
+
+		names := strings.Split(f.Name, ",")
+
+		r.Flags = append(r.Flags, &JSONFlag{
+			Name:        names[0],
+			Aliases:     names[1:],
+			Type:        f.TypeName(),
+			Usage:       strings.TrimPrefix(f.Placeholder(), "="),
+			Description: f.Description,
+			Help:        f.Help,
+			Group:       f.Group,
+			Default:     f.DefaultString(),
+			Env:         FlagEnv(c, f),
+			Hidden:      f.Hidden,
+			Local:       f.Local,
+			Required:    f.Required,
+			Secret:      f.Secret,
+		})
+	}
+
+	for _, sub := range c.Commands {
+		if sub == nil || sub.Name == "" || sub.Hidden && !hidden {
+			continue
+		}
+
+		r.Commands = append(r.Commands, exportCommand(sub, hidden))
+	}
+
+	for _, e := range c.Examples {
+		r.Examples = append(r.Examples, &JSONExample{
+			Command:     e.Command,
+			Description: e.Description,
+			Output:      e.Output,
+		})
+	}
+
+	return r
+}
+
+// exportArgsUsage is the usage without the leading [flags] placeholder.
+// [args] stands for the generated usage.
+func exportArgsUsage(c *Command, usage string) string {
+	if c.Args == nil {
+		return ""
+	}
+
+	if usage == "[flags_and_args]" {
+		return "[args]"
+	}
+
+	return strings.TrimSpace(strings.TrimPrefix(usage, "[flags]"))
+}
+
+// printJSON prints the command subtree export to c.Stdout.
+func printJSON(c *Command, hidden bool) error {
+	data, err := json.MarshalIndent(ExportJSON(c, hidden), "", "  ")
+	if err != nil {
+		return wrap(err, "marshal")
+	}
+
+	data = append(data, '\n')
+
+	_, err = c.Stdout.Write(data)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nikandfor/assert"
+	"nikand.dev/go/cli/flag"
+)
+
+func TestExportJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name:      "app,a",
+		EnvPrefix: "APP_",
+		Flags: []*Flag{
+			flag.New("verbose,v", false, "verbose output"),
+			flag.New("token", "", "api token", flag.Secret, flag.Required),
+			flag.New("debug", false, "debug", flag.Hidden),
+			HelpFlag,
+		},
+		Commands: []*Command{{
+			Name:        "get",
+			Group:       "Basic",
+			Description: "get objects",
+			Args:        Args{},
+			Flags: []*Flag{
+				flag.New("timeout", time.Second, "timeout", flag.Local),
+			},
+			Examples: []Example{{Command: "app get obj"}},
+		}, {
+			Name:   "secret",
+			Hidden: true,
+		}},
+		Stdout: &buf,
+	}
+
+	err := Run(c, []string{"app", "--help=json"}, []string{"APP_TOKEN=tok"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{
+  "version": 1,
+  "command": {
+    "name": "app",
+    "aliases": [
+      "a"
+    ],
+    "path": [
+      "app"
+    ],
+    "usage": "[flags]",
+    "args": false,
+    "flags": [
+      {
+        "name": "verbose",
+        "aliases": [
+          "v"
+        ],
+        "type": "bool",
+        "description": "verbose output",
+        "default": "false",
+        "env": "APP_VERBOSE"
+      },
+      {
+        "name": "token",
+        "type": "string",
+        "usage": "string",
+        "description": "api token",
+        "env": "APP_TOKEN",
+        "required": true,
+        "secret": true
+      },
+      {
+        "name": "help",
+        "aliases": [
+          "h"
+        ],
+        "usage": "[hidden][,json|search:text]",
+        "description": "print command help end exit",
+        "env": "APP_HELP"
+      }
+    ],
+    "commands": [
+      {
+        "name": "get",
+        "path": [
+          "app",
+          "get"
+        ],
+        "usage": "[flags_and_args]",
+        "description": "get objects",
+        "group": "Basic",
+        "args": true,
+        "args_usage": "[args]",
+        "flags": [
+          {
+            "name": "timeout",
+            "type": "duration",
+            "usage": "duration",
+            "description": "timeout",
+            "default": "1s",
+            "env": "APP_TIMEOUT",
+            "local": true
+          }
+        ],
+        "examples": [
+          {
+            "command": "app get obj"
+          }
+        ]
+      }
+    ]
+  }
+}
+`, buf.String())
+
+	d := ExportJSON(&Command{Name: "cp", Usage: "[flags] <src>... <dst>", Args: Args{}}, false)
+	assert.Equal(t, "<src>... <dst>", d.Command.ArgsUsage)
+}
+
+func TestJSONSchema(t *testing.T) {
+	var s struct {
+		Properties map[string]interface{} `json:"properties"`
+		Defs       map[string]struct {
+			Required   []string               `json:"required"`
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"$defs"`
+	}
+
+	err := json.Unmarshal([]byte(JSONSchema), &s)
+	assert.NoError(t, err)
+
+	for name, v := range map[string]interface{}{
+		"":        JSONDoc{},
+		"command": JSONCommand{},
+		"flag":    JSONFlag{},
+		"example": JSONExample{},
+	} {
+		props := s.Properties
+		if name != "" {
+			props = s.Defs[name].Properties
+		}
+
+		var exp, got []string
+
+		for k := range props {
+			exp = append(exp, k)
+		}
+
+		tp := reflect.TypeOf(v)
+
+		for i := 0; i < tp.NumField(); i++ {
+			got = append(got, strings.Split(tp.Field(i).Tag.Get("json"), ",")[0])
+		}
+
+		sort.Strings(exp)
+		sort.Strings(got)
+
+		assert.Equal(t, exp, got, "%v", name)
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	stderrors "errors"
+	"strings"
 
 	"nikand.dev/go/cli/flag"
 )
@@ -52,3 +53,29 @@ func flagName(arg string) string {
 
 	return arg[st:end]
 }
+
+// scopeFlags returns the command flags and the inherited parent flags
+// which can be referred to from the command by at least one name.
+// Local parent flags and names shadowed by the closer flags are skipped as the parser does.
+func scopeFlags(c *Command) (res []*Flag) {
+	seen := map[*Flag]bool{}
+
+	for q := c; q != nil; q = q.Parent {
+		for _, f := range groupedFlags(q) {
+			if seen[f] {
+				continue
+			}
+
+			seen[f] = true
+
+			for _, name := range strings.Split(f.Name, ",") {
+				if c.Flag(name) == f {
+					res = append(res, f)
+					break
+				}
+			}
+		}
+	}
+
+	return res
+}
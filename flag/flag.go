@@ -12,29 +12,41 @@ type (
 	Flag struct {
 		Name        string
 		Group       string
-		Usage       string
+		Usage       string // value usage, derived from the value type if empty, see Placeholder
 		Description string
 		Help        string
+		DefaultText string // default value text for help instead of the formatted Value
 
-		Action Action  // flag parser
-		Check  Visitor // called after all parsing but before command action for all flags
-		//	Complete Visitor
+		Action   Action    // flag parser
+		Check    Visitor   // called after all parsing but before command action for all flags
+		Complete Completer // value completion
 
 		Hidden   bool // not shown in a help by default
 		Required bool // must be set from args or env var
 		Local    bool // do not inherited by child
+		Secret   bool // value is never printed, see Redacted
 
 		IsSet bool
 
 		Value interface{}
 
 		CurrentCommand interface{}
+
+		resolve int  // value references mode, see ValueRef
+		trim    bool // trim final newline of referenced values, see TrimNewline
+
+		def    string // formatted Value before parsing, see SaveDefault
+		hasDef bool
 	}
 
 	Action  func(f *Flag, arg string, args []string) ([]string, error)
 	Visitor func(f *Flag) error
 	Option  = func(f *Flag)
 
+	// Completer returns value completion candidates starting with prefix.
+	// f.CurrentCommand is the partially parsed command.
+	Completer func(f *Flag, prefix string) ([]string, error)
+
 	// Setter is subset of stdlib flag.Value interface
 	Setter interface {
 		Set(v string) error
@@ -103,6 +115,13 @@ func (f *Flag) MainName() string {
 	return f.Name[:p]
 }
 
+// SaveDefault saves the formatted Value as the default shown by DefaultString.
+// Parsers call it before parsing, so values from args and env vars are not shown as defaults.
+func (f *Flag) SaveDefault() {
+	f.def = FormatValue(f.Value)
+	f.hasDef = true
+}
+
 func CheckFlag(f *Flag) error {
 	if f.Check != nil {
 		return f.Check(f)
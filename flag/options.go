@@ -1,9 +1,6 @@
 package flag
 
-import (
-	"fmt"
-	"os"
-)
+import "os"
 
 func Default(v interface{}) Option {
 	return func(f *Flag) {
@@ -11,6 +8,13 @@ func Default(v interface{}) Option {
 	}
 }
 
+// DefaultText sets the default value text for help.
+func DefaultText(text string) Option {
+	return func(f *Flag) {
+		f.DefaultText = text
+	}
+}
+
 func Hidden(f *Flag) {
 	f.Hidden = true
 }
@@ -23,27 +27,20 @@ func Local(f *Flag) {
 	f.Local = true
 }
 
-// AtFile replaces flag value of the form @file with the file contents.
-func AtFile(f *Flag) {
-	orig := f.Action
+// Secret marks the flag value as secret, so it's never printed.
+// It also enables value references (see ValueRef), so the value can be passed as @file or @-
+// instead of appearing in the command line. Resolved values are redacted from parse errors too.
+// It can be combined with ValueRef or AtFile, references are resolved only once.
+func Secret(f *Flag) {
+	f.Secret = true
 
-	f.Action = func(f *Flag, arg string, args []string) ([]string, error) {
-		key, val, args, err := ParseArg(arg, args, true, false)
-		if err != nil {
-			return args, err
-		}
-
-		if len(val) != 0 && val[0] == '@' {
-			data, err := readFile(val[1:])
-			if err != nil {
-				return nil, fmt.Errorf("read file: %w", err)
-			}
-
-			arg = key + "=" + string(data)
-		}
+	ValueRef(f)
+}
 
-		return orig(f, arg, args)
-	}
+// TrimNewline trims one final newline from values taken by references, like file content.
+// Literal values are kept as is. It's used along with ValueRef, AtFile or Secret.
+func TrimNewline(f *Flag) {
+	f.trim = true
 }
 
 var readFile = os.ReadFile
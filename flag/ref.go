@@ -0,0 +1,230 @@
+package flag
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Resolver returns the value referenced by ref.
+	// ref is the part of the value after the scheme and a colon.
+	Resolver func(f *Flag, ref string) (string, error)
+
+	// LookupEnver is used by env: references. cli.Command implements it.
+	LookupEnver interface {
+		LookupEnv(key string) (string, bool)
+	}
+
+	// StdinReader is used by @- and fd:0 references. cli.Command implements it.
+	StdinReader interface {
+		StdinReader() io.Reader
+	}
+)
+
+// Resolvers is a registry of value reference schemes used by ValueRef.
+// Add your own or replace existing ones.
+var Resolvers = map[string]Resolver{
+	"env":  ResolveEnv,
+	"file": ResolveFile,
+	"fd":   ResolveFD,
+}
+
+var (
+	ErrNoSuchEnv = errors.New("no such env var")
+	ErrOutputFD  = errors.New("stdout and stderr can't be read")
+)
+
+// Flag.resolve modes set by wrapResolve.
+const (
+	resolveAt = 1 + iota
+	resolveSchemes
+)
+
+// ValueRef replaces flag value references with the referenced values.
+//
+//	@path       - file content
+//	@-          - stdin
+//	@@value     - literal @value
+//	env:NAME    - env var value
+//	file://path - file content
+//	fd:3        - content read from the file descriptor
+//	raw:value   - literal value, even if it looks like a reference
+//
+// Schemes are taken from Resolvers, so more can be added.
+// Values with unknown schemes are used as is.
+func ValueRef(f *Flag) {
+	wrapResolve(f, true)
+}
+
+// AtFile replaces flag value of the form @file with the file contents.
+// @- reads stdin and @@value escapes a literal @value.
+// See ValueRef for more reference types.
+func AtFile(f *Flag) {
+	wrapResolve(f, false)
+}
+
+// Resolve returns the value referenced by val.
+// If schemes is false only @ references are resolved.
+// One final newline is trimmed from referenced values if the flag has TrimNewline option.
+func Resolve(f *Flag, val string, schemes bool) (string, error) {
+	v, ref, err := resolve(f, val, schemes)
+	if err != nil || !ref || !f.trim {
+		return v, err
+	}
+
+	if strings.HasSuffix(v, "\n") {
+		v = strings.TrimSuffix(v[:len(v)-1], "\r")
+	}
+
+	return v, nil
+}
+
+func resolve(f *Flag, val string, schemes bool) (_ string, ref bool, err error) {
+	switch {
+	case strings.HasPrefix(val, "@@"):
+		return val[1:], false, nil
+	case val == "@-":
+		val, err = ResolveFD(f, "0")
+		return val, true, err
+	case strings.HasPrefix(val, "@") && len(val) > 1:
+		val, err = ResolveFile(f, val[1:])
+		return val, true, err
+	case !schemes:
+		return val, false, nil
+	case strings.HasPrefix(val, "raw:"):
+		return val[4:], false, nil
+	}
+
+	p := strings.IndexByte(val, ':')
+	if p <= 0 {
+		return val, false, nil
+	}
+
+	r, ok := Resolvers[val[:p]]
+	if !ok {
+		return val, false, nil
+	}
+
+	val, err = r(f, val[p+1:])
+
+	return val, true, err
+}
+
+// Literal returns val escaped so the flag value references keep it as is.
+// It's used to pass a value which is not to be resolved, like a typed in one.
+func Literal(f *Flag, val string) string {
+	switch {
+	case f.resolve == resolveSchemes:
+		return "raw:" + val
+	case f.resolve == resolveAt && strings.HasPrefix(val, "@"):
+		return "@" + val
+	}
+
+	return val
+}
+
+func wrapResolve(f *Flag, schemes bool) {
+	mode := resolveAt
+	if schemes {
+		mode = resolveSchemes
+	}
+
+	if f.resolve != 0 { // already wrapped
+		if mode > f.resolve {
+			f.resolve = mode
+		}
+
+		return
+	}
+
+	f.resolve = mode
+	orig := f.Action
+
+	f.Action = func(f *Flag, arg string, args []string) ([]string, error) {
+		key, val, args, err := ParseArg(arg, args, true, false)
+		if err != nil {
+			return args, err
+		}
+
+		val, err = Resolve(f, val, f.resolve == resolveSchemes)
+		if err != nil {
+			return nil, err
+		}
+
+		args, err = orig(f, key+"="+val, args)
+		if f.Secret {
+			err = RedactError(err, val)
+		}
+
+		return args, err
+	}
+}
+
+// ResolveEnv returns env var value.
+// The command's env is used if f.CurrentCommand implements LookupEnver.
+func ResolveEnv(f *Flag, name string) (string, error) {
+	lookup := os.LookupEnv
+
+	if e, ok := f.CurrentCommand.(LookupEnver); ok {
+		lookup = e.LookupEnv
+	}
+
+	v, ok := lookup(name)
+	if !ok {
+		return "", fmt.Errorf("%w: %v", ErrNoSuchEnv, name)
+	}
+
+	return v, nil
+}
+
+// ResolveFile returns file content. Both file:path and file://path forms are supported.
+func ResolveFile(f *Flag, path string) (string, error) {
+	path = strings.TrimPrefix(path, "//")
+
+	data, err := readFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// ResolveFD reads all the data from the file descriptor.
+// fd 0 is the command's stdin if f.CurrentCommand implements StdinReader.
+// Other descriptors are consumed: they are closed after reading, so they can't be used afterwards.
+// fd 1 and 2 are rejected with ErrOutputFD.
+func ResolveFD(f *Flag, fd string) (string, error) {
+	n, err := strconv.ParseUint(fd, 10, 32)
+	if err != nil {
+		return "", fmt.Errorf("parse fd: %w", err)
+	}
+
+	if n == 1 || n == 2 {
+		return "", fmt.Errorf("fd %v: %w", n, ErrOutputFD)
+	}
+
+	var r io.Reader
+
+	switch c, ok := f.CurrentCommand.(StdinReader); {
+	case n == 0 && ok:
+		r = c.StdinReader()
+	case n == 0:
+		r = os.Stdin
+	default:
+		file := os.NewFile(uintptr(n), "fd:"+fd)
+		defer file.Close()
+
+		r = file
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("read fd %v: %w", fd, err)
+	}
+
+	return string(data), nil
+}
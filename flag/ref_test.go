@@ -0,0 +1,163 @@
+package flag
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/nikandfor/assert"
+)
+
+type testCommand struct {
+	env   map[string]string
+	stdin io.Reader
+}
+
+func (c testCommand) LookupEnv(k string) (v string, ok bool) {
+	v, ok = c.env[k]
+	return
+}
+
+func (c testCommand) StdinReader() io.Reader { return c.stdin }
+
+func TestValueRef(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+
+	readFile = func(n string) ([]byte, error) {
+		if n == "secret.txt" {
+			return []byte("file_value\n"), nil
+		}
+
+		return nil, errors.New("no file")
+	}
+
+	Resolvers["upper"] = func(f *Flag, ref string) (string, error) {
+		return strings.ToUpper(ref), nil
+	}
+	defer delete(Resolvers, "upper")
+
+	for _, tc := range []struct {
+		arg  string
+		args []string
+		exp  string
+	}{
+		{arg: "--f=plain", exp: "plain"},
+		{arg: "--f=@secret.txt", exp: "file_value\n"},
+		{arg: "--f", args: []string{"@-"}, exp: "stdin_value"},
+		{arg: "--f=@@literal", exp: "@literal"},
+		{arg: "--f=env:NAME", exp: "env_value"},
+		{arg: "--f=file://secret.txt", exp: "file_value\n"},
+		{arg: "--f=file:secret.txt", exp: "file_value\n"},
+		{arg: "--f=fd:0", exp: "stdin_value"},
+		{arg: "--f=upper:abc", exp: "ABC"},
+		{arg: "--f=http://example.com", exp: "http://example.com"},
+		{arg: "--f=raw:env:NAME", exp: "env:NAME"},
+		{arg: "--f=raw:file:secret.txt", exp: "file:secret.txt"},
+		{arg: "--f=raw:@secret.txt", exp: "@secret.txt"},
+		{arg: "--f=raw:raw:x", exp: "raw:x"},
+	} {
+		f := New("f", "", "", ValueRef)
+		f.CurrentCommand = testCommand{
+			env:   map[string]string{"NAME": "env_value"},
+			stdin: strings.NewReader("stdin_value"),
+		}
+
+		rest, err := f.Action(f, tc.arg, append(tc.args, "next"))
+		assert.NoError(t, err, "arg %v", tc.arg)
+		assert.Equal(t, []string{"next"}, rest, "arg %v", tc.arg)
+		assert.Equal(t, tc.exp, f.Value, "arg %v", tc.arg)
+	}
+
+	f := New("f", "", "", ValueRef)
+	f.CurrentCommand = testCommand{}
+
+	_, err := f.Action(f, "--f=env:NONE", nil)
+	assert.ErrorIs(t, err, ErrNoSuchEnv)
+
+	_, err = f.Action(f, "--f=fd:1", nil)
+	assert.ErrorIs(t, err, ErrOutputFD)
+
+	_, err = f.Action(f, "--f=fd:2", nil)
+	assert.ErrorIs(t, err, ErrOutputFD)
+
+	f = New("f", "", "", AtFile)
+
+	_, err = f.Action(f, "--f=env:NAME", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "env:NAME", f.Value)
+
+	_, err = f.Action(f, "--f=raw:x", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "raw:x", f.Value, "raw: is a scheme, so only for ValueRef")
+}
+
+func TestTrimNewline(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+
+	readFile = func(n string) ([]byte, error) {
+		return []byte("file_value\r\n"), nil
+	}
+
+	for _, tc := range []struct {
+		arg string
+		exp string
+	}{
+		{arg: "--f=@secret.txt", exp: "file_value"},
+		{arg: "--f=file:secret.txt", exp: "file_value"},
+		{arg: "--f", exp: "stdin_value"},
+		{arg: "--f=literal\n", exp: "literal\n"},
+	} {
+		f := New("f", "", "", ValueRef, TrimNewline)
+		f.CurrentCommand = testCommand{stdin: strings.NewReader("stdin_value\n")}
+
+		_, err := f.Action(f, tc.arg, []string{"@-"})
+		assert.NoError(t, err, "arg %v", tc.arg)
+		assert.Equal(t, tc.exp, f.Value, "arg %v", tc.arg)
+	}
+
+	f := New("f", "", "", ValueRef)
+
+	_, err := f.Action(f, "--f=@secret.txt", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "file_value\r\n", f.Value, "other flags are not affected")
+}
+
+func TestSecretValueRef(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+
+	readFile = func(n string) ([]byte, error) {
+		switch n {
+		case "pin.txt":
+			return []byte("hunter2\n"), nil
+		case "ref.txt":
+			return []byte("@pin.txt"), nil
+		}
+
+		return nil, errors.New("no file")
+	}
+
+	f := New("pin", 0, "", Secret)
+
+	_, err := f.Action(f, "--pin=@pin.txt", nil)
+	assert.Error(t, err)
+	assert.False(t, strings.Contains(err.Error(), "hunter2"), "%v", err)
+
+	for _, opts := range [][]Option{
+		{Secret, ValueRef},
+		{ValueRef, Secret},
+		{Secret, AtFile},
+		{AtFile, Secret},
+	} {
+		f := New("f", "", "", opts...)
+		f.CurrentCommand = testCommand{env: map[string]string{"NAME": "env_value"}}
+
+		_, err := f.Action(f, "--f=@ref.txt", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "@pin.txt", f.Value, "resolved once")
+
+		_, err = f.Action(f, "--f=env:NAME", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "env_value", f.Value)
+	}
+}
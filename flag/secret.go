@@ -0,0 +1,59 @@
+package flag
+
+import (
+	"strconv"
+	"strings"
+)
+
+type (
+	redactedError struct {
+		err     error
+		secrets []string
+	}
+)
+
+// Redacted is printed instead of secret values.
+var Redacted = "******"
+
+// RedactError replaces secrets in the error message with Redacted.
+// The original error is still available through errors.Unwrap.
+func RedactError(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	return redactedError{err: err, secrets: secrets}
+}
+
+// RedactArg replaces the value in --flag=value arg with Redacted.
+func RedactArg(arg string) string {
+	p := strings.IndexAny(arg, "= ")
+	if p == -1 {
+		return arg
+	}
+
+	return arg[:p+1] + Redacted
+}
+
+func (e redactedError) Error() string {
+	s := e.err.Error()
+
+	for _, v := range e.secrets {
+		if v == "" {
+			continue
+		}
+
+		q := strconv.Quote(v)
+
+		s = strings.ReplaceAll(s, v, Redacted)
+		s = strings.ReplaceAll(s, q[1:len(q)-1], Redacted) // as it's printed by %q
+
+		if t := strings.TrimSpace(v); t != "" {
+			s = strings.ReplaceAll(s, t, Redacted)
+		}
+	}
+
+	return s
+}
+
+func (e redactedError) Unwrap() error { return e.err }
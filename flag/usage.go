@@ -0,0 +1,166 @@
+package flag
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type (
+	// Typer is implemented by values which know their type name for help.
+	// pflag.Value implements it.
+	Typer interface {
+		Type() string
+	}
+)
+
+// actionTypes are used for flags without values.
+var actionTypes = map[uintptr]string{}
+
+func init() {
+	for _, a := range []struct {
+		act Action
+		p   string
+	}{
+		{ParseBool, "bool"},
+		{ParseDuration, "duration"},
+		{ParseFloat64, "float"},
+		{ParseFloat32, "float"},
+		{ParseInt, "int"},
+		{ParseInt64, "int"},
+		{ParseUint, "uint"},
+		{ParseUint64, "uint"},
+		{ParseString, "string"},
+		{ParseStringSlice, "string..."},
+	} {
+		actionTypes[reflect.ValueOf(a.act).Pointer()] = a.p
+	}
+}
+
+// Placeholder returns the value usage for help.
+// It's Usage if set, otherwise it's derived from the value type or from the action.
+// For example "=int", "=duration", "=string...", or "" for bool flags.
+func (f *Flag) Placeholder() string {
+	if f.Usage != "" {
+		return f.Usage
+	}
+
+	p := f.TypeName()
+	if p == "" || p == "bool" {
+		return ""
+	}
+
+	return "=" + p
+}
+
+// TypeName returns the value type name, like "bool", "int", "duration" or "string...".
+// It's derived from the value type or from the action.
+// It's empty if neither is known.
+func (f *Flag) TypeName() string {
+	if f.Value == nil && f.Action != nil {
+		return actionTypes[reflect.ValueOf(f.Action).Pointer()]
+	}
+
+	if _, ok := f.Value.(bool); ok {
+		return "bool"
+	}
+
+	return TypePlaceholder(f.Value)
+}
+
+// TypedAction reports whether the flag action is one of the typed value parsers like ParseInt.
+// Typed parsers only set the flag value and have no other side effects.
+func (f *Flag) TypedAction() bool {
+	if f.Action == nil {
+		return false
+	}
+
+	_, ok := actionTypes[reflect.ValueOf(f.Action).Pointer()]
+
+	return ok
+}
+
+// Repeatable reports whether the flag accumulates values when given multiple times, like []string flags.
+// Other flags are overwritten by the last occurrence.
+func (f *Flag) Repeatable() bool {
+	if _, ok := f.Value.([]string); ok {
+		return true
+	}
+
+	return f.Action != nil && reflect.ValueOf(f.Action).Pointer() == reflect.ValueOf(ParseStringSlice).Pointer()
+}
+
+// TypePlaceholder returns the value type name for help.
+func TypePlaceholder(v interface{}) string {
+	switch v := v.(type) {
+	case nil, bool:
+		return ""
+	case time.Duration:
+		return "duration"
+	case int, int64, int32, int16, int8:
+		return "int"
+	case uint, uint64, uint32, uint16, uint8:
+		return "uint"
+	case float64, float32:
+		return "float"
+	case string:
+		return "string"
+	case []string:
+		return "string..."
+	case Typer:
+		return v.Type()
+	default:
+		return "value"
+	}
+}
+
+// DefaultString returns the default value text for help.
+// It's DefaultText if set, or the Value before parsing, see SaveDefault. It's empty for secret flags.
+func (f *Flag) DefaultString() string {
+	switch {
+	case f.Secret:
+		return ""
+	case f.DefaultText != "":
+		return f.DefaultText
+	}
+
+	if f.hasDef {
+		return f.def
+	}
+
+	return FormatValue(f.Value)
+}
+
+// FormatValue formats the value as it would be passed in args.
+// Stringers are used as is and pointers are dereferenced.
+func FormatValue(v interface{}) string {
+	rv := reflect.ValueOf(v)
+
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return ""
+		}
+
+		if s, ok := rv.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+
+		rv = rv.Elem()
+	}
+
+	if !rv.IsValid() {
+		return ""
+	}
+
+	switch v := rv.Interface().(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	return fmt.Sprintf("%v", rv.Interface())
+}
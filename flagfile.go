@@ -13,8 +13,10 @@ import (
 // # comments are also supported.
 var FlagfileFlag = &Flag{
 	Name:        "flagfile,ff",
+	Usage:       "=file",
 	Description: "load flags from file",
 	Action:      flagfile,
+	Complete:    FlagCompleter(CompleteFiles()),
 }
 
 var readFile = os.ReadFile
@@ -30,7 +32,17 @@ func flagfile(f *Flag, arg string, args []string) (_ []string, err error) {
 		return nil, wrap(err, "read file")
 	}
 
-	var add []string
+	add, err := splitArgs(d, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(add, args...), nil
+}
+
+// splitArgs splits flagfile content into args.
+// # comments are skipped if comments is true.
+func splitArgs(d []byte, comments bool) (add []string, err error) {
 	var buf []byte
 
 	for i := 0; i < len(d); i++ {
@@ -39,7 +51,7 @@ func flagfile(f *Flag, arg string, args []string) (_ []string, err error) {
 			break
 		}
 
-		if d[i] == '#' {
+		if comments && d[i] == '#' {
 			i = skip(d, i, untilNewline)
 			continue
 		}
@@ -52,7 +64,7 @@ func flagfile(f *Flag, arg string, args []string) (_ []string, err error) {
 		add = append(add, string(buf))
 	}
 
-	return append(add, args...), nil
+	return add, nil
 }
 
 func decodeArg(d []byte, i int, buf []byte) ([]byte, int, error) {
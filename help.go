@@ -3,21 +3,207 @@ package cli
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
+	"text/template"
 
 	"nikand.dev/go/cli/flag"
+	"nikand.dev/go/cli/term"
+)
+
+type (
+	// HelpRenderer renders command help.
+	// It's set by Command.HelpRenderer and inherited by subcommands.
+	HelpRenderer interface {
+		RenderHelp(w io.Writer, h *HelpData) error
+	}
+
+	// TemplateHelp is a HelpRenderer executing text/template with HelpData.
+	TemplateHelp struct {
+		Template *template.Template
+	}
+
+	// HelpData is everything help renderers need to know about the command.
+	HelpData struct {
+		Command *Command
+
+		Name        string // command name with aliases
+		Path        string // full command name starting from the root
+		Usage       string // Command.Usage or generated one
+		Description string
+		Help        string
+
+		Hidden bool // hidden items are included
+
+		Width int  // text is wrapped to the width, 0 means no wrapping
+		Color bool // headings, names and defaults are colored
+
+		Commands      []HelpItem  // visible subcommands
+		CommandGroups []HelpGroup // the same subcommands grouped by Group
+		Flags         []HelpFlags // visible flags grouped by owning command, current command first
+		Topics        []HelpItem  // help topics registered on the command
+		Examples      []Example   // command examples, Output without final newlines
+	}
+
+	// HelpGroup is a set of items with the same Group.
+	HelpGroup struct {
+		Name  string // empty for ungrouped items
+		Items []HelpItem
+	}
+
+	// HelpFlags are flags owned by one command.
+	HelpFlags struct {
+		Command *Command
+
+		Name   string // owning command main name
+		Parent bool   // flags are inherited from a parent command
+
+		Flags  []HelpItem
+		Groups []HelpGroup // the same flags grouped by Group
+	}
+
+	// HelpItem is a subcommand or a flag.
+	// Separator and Header items come from nil and unnamed entries of Command.Commands and Command.Flags.
+	HelpItem struct {
+		Name        string // name with aliases
+		Usage       string
+		Description string
+		Default     string
+		Group       string
+		Required    bool
+
+		Separator bool // empty line
+		Header    bool // Description is a header comment
+
+		Command *Command   // nil for flags
+		Flag    *Flag      // nil for commands
+		Topic   *HelpTopic // set for topics
+	}
 )
 
 var HelpFlag = &Flag{
 	Name:        "help,h",
-	Usage:       "=[hidden]",
+	Usage:       "=[hidden][,json|search:text]",
 	Description: "print command help end exit",
 	Action:      defaultHelp,
 }
 
-func defaultHelp(f *Flag, arg string, args []string) (rest []string, err error) {
-	const minNameW, maxNameW = 20, 40
+// DefaultHelpTemplate is used by DefaultHelpRenderer.
+const DefaultHelpTemplate = `{{ .Name }} {{ .Usage }}{{ with .Description }} - {{ . }}{{ end }}
+{{ with .Help }}
+{{ wrap . }}
+{{ end }}
+{{- $w := nameWidth .Commands }}
+{{- range .CommandGroups }}
+{{ heading (or .Name "Subcommands") }}
+{{ range .Items }}{{ line $w . }}{{ end }}
+{{- end }}
+{{- with .Topics }}
+{{ heading "Help topics" }}
+{{ range . }}{{ line $w . }}{{ end }}
+{{- end }}
+{{- range $fs := .Flags }}
+{{- $w := nameWidth .Flags }}
+{{- range .Groups }}
+{{ if and $fs.Parent .Name }}{{ heading (print .Name " (parent command " $fs.Name ")") }}
+{{- else if $fs.Parent }}{{ heading (print "Flags of parent command " $fs.Name) }}
+{{- else }}{{ heading (or .Name "Flags") }}{{ end }}
+{{ range .Items }}{{ line $w . }}{{ end }}
+{{- end }}
+{{- end }}
+{{- with .Examples }}
+{{ heading "Examples" }}
+{{ range $i, $e := . }}{{ if $i }}
+{{ end }}{{ with .Description }}    # {{ . }}
+{{ end }}    $ {{ .Command }}
+{{ with .Output }}{{ indent 4 . }}
+{{ end }}{{ end }}
+{{- end }}`
+
+// HelpFuncs are available in help templates.
+// line, heading and wrap are bound to the HelpData being rendered.
+//
+//	nameWidth items    - names column width for the items
+//	line width item    - item line as rendered by the default template
+//	heading s          - s colored as a heading if colors are enabled
+//	wrap s             - s wrapped to the terminal width
+//	pad width s        - s padded with spaces to the width
+//	indent n s         - s with every line indented by n spaces
+var HelpFuncs = template.FuncMap{
+	"nameWidth": helpNameWidth,
+	"line":      (&HelpData{}).line,
+	"heading":   (&HelpData{}).heading,
+	"wrap":      (&HelpData{}).wrap,
+	"pad":       helpPad,
+	"indent":    helpIndent,
+}
+
+// Help colors are ANSI escape sequences used if colors are enabled.
+var (
+	HelpColorHeading = "\x1b[1m"
+	HelpColorName    = "\x1b[36m"
+	HelpColorDefault = "\x1b[2m"
+	HelpColorReset   = "\x1b[0m"
+)
+
+var DefaultHelpRenderer HelpRenderer = MustTemplateHelp(DefaultHelpTemplate)
+
+// NewTemplateHelp parses the template text with HelpFuncs.
+func NewTemplateHelp(text string) (*TemplateHelp, error) {
+	t, err := template.New("help").Funcs(HelpFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TemplateHelp{Template: t}, nil
+}
+
+// MustTemplateHelp is NewTemplateHelp which panics on error.
+func MustTemplateHelp(text string) *TemplateHelp {
+	h, err := NewTemplateHelp(text)
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+func (h *TemplateHelp) RenderHelp(w io.Writer, d *HelpData) error {
+	t, err := h.Template.Clone()
+	if err != nil {
+		return err
+	}
+
+	t.Funcs(template.FuncMap{
+		"line":    d.line,
+		"heading": d.heading,
+		"wrap":    d.wrap,
+	})
+
+	return t.Execute(w, d)
+}
+
+// PrintHelp renders the command help to the command Stdout.
+func PrintHelp(c *Command, hidden bool) error {
+	d := NewHelpData(c, hidden)
+
+	var b bytes.Buffer
+
+	err := c.helpRenderer().RenderHelp(&b, d)
+	if err != nil {
+		return wrap(err, "render")
+	}
+
+	_, err = b.WriteTo(c.Stdout)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
 
+func defaultHelp(f *Flag, arg string, args []string) (rest []string, err error) {
 	c := f.CurrentCommand.(*Command)
 
 	_, v, rest, err := flag.ParseArg(arg, args, false, true)
@@ -25,175 +211,475 @@ func defaultHelp(f *Flag, arg string, args []string) (rest []string, err error)
 		return
 	}
 
-	hidden := v == "hidden"
+	hidden := v == "hidden" || strings.HasPrefix(v, "hidden,")
+	if hidden {
+		v = strings.TrimPrefix(v[len("hidden"):], ",")
+	}
+
+	switch {
+	case v == "json":
+		err = printJSON(c, hidden)
+	case strings.HasPrefix(v, "search:"):
+		root := c
+		for root.Parent != nil {
+			root = root.Parent
+		}
+
+		err = printSearch(c, root, v[len("search:"):], hidden)
+	default:
+		err = PrintHelp(c, hidden)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, ErrExit
+}
+
+// NewHelpData collects the command help data.
+// Hidden items are included if hidden is true.
+func NewHelpData(c *Command, hidden bool) *HelpData {
+	d := &HelpData{
+		Command:     c,
+		Name:        c.Name,
+		Path:        strings.Join(FullName(c), " "),
+		Usage:       c.Usage,
+		Description: c.Description,
+		Help:        c.Help,
+		Hidden:      hidden,
+	}
+
+	d.Width, d.Color = helpTerminal(c)
 
-	b := new(bytes.Buffer)
+	if d.Usage == "" && c.Args != nil {
+		d.Usage = "[flags_and_args]"
+	} else if d.Usage == "" {
+		d.Usage = "[flags]"
+	}
 
-	pline := func(name, usage, desc string, w int, val interface{}) {
-		name += usage
+	for _, sub := range c.Commands {
+		switch {
+		case sub == nil:
+			d.Commands = append(d.Commands, HelpItem{Separator: true})
+		case sub.Name == "":
+			d.Commands = appendHelpHeader(d.Commands, sub.Description)
+		case sub.Hidden && !hidden:
+		default:
+			d.Commands = append(d.Commands, HelpItem{
+				Name:        sub.Name,
+				Description: sub.Description,
+				Group:       sub.Group,
+				Command:     sub,
+			})
+		}
+	}
+
+	if !hasHelpItems(d.Commands) {
+		d.Commands = nil
+	}
 
-		fmt.Fprintf(b, "    %-*s", w, name)
+	d.CommandGroups = groupHelpItems(d.Commands, c.Groups)
 
-		if len(name) > w {
-			fmt.Fprintf(b, "\n    %-*s", w, "")
+	for _, t := range c.Topics {
+		if t.Hidden && !hidden {
+			continue
 		}
 
-		lines := strings.Split(desc, "\n")
+		d.Topics = append(d.Topics, HelpItem{
+			Name:        t.Name,
+			Description: t.Description,
+			Topic:       t,
+		})
+	}
 
-		for i, l := range lines {
-			if i == 0 {
-				fmt.Fprintf(b, " - ")
-			} else {
-				fmt.Fprintf(b, "\n    %-*s   ", w, "")
-			}
+	for _, e := range c.Examples {
+		e.Output = strings.TrimRight(e.Output, "\n")
 
-			fmt.Fprintf(b, "%s", l)
+		d.Examples = append(d.Examples, e)
+	}
+
+	for cc := c; cc != nil; cc = cc.Parent {
+		fs := HelpFlags{
+			Command: cc,
+			Name:    cc.MainName(),
+			Parent:  cc != c,
 		}
 
-		if val != nil && val != "" {
-			if len(lines) != 0 && lines[len(lines)-1] == "" {
-				fmt.Fprintf(b, "default %v", val)
-			} else {
-				fmt.Fprintf(b, " (default %v)", val)
+		for _, f := range cc.Flags {
+			switch {
+			case f == nil:
+				fs.Flags = append(fs.Flags, HelpItem{Separator: true})
+			case f.Name == "":
+				fs.Flags = appendHelpHeader(fs.Flags, f.Description)
+			case f.Hidden && !hidden || cc != c && f.Local:
+			default:
+				fs.Flags = append(fs.Flags, HelpItem{
+					Name:        f.Name,
+					Usage:       f.Placeholder(),
+					Description: f.Description,
+					Default:     f.DefaultString(),
+					Group:       f.Group,
+					Required:    f.Required,
+					Flag:        f,
+				})
 			}
 		}
 
-		fmt.Fprintf(b, "\n")
+		if hasHelpItems(fs.Flags) {
+			fs.Groups = groupHelpItems(fs.Flags, cc.Groups)
+			d.Flags = append(d.Flags, fs)
+		}
 	}
 
-	//	full := FullName(c.Parent)
-	//	if full != nil {
-	//		fmt.Fprintf(b, "%s ", strings.Join(full, " "))
-	//	}
+	return d
+}
 
-	fmt.Fprintf(b, "%s", c.Name)
+// appendHelpHeader separates the header from the previous items.
+func appendHelpHeader(items []HelpItem, desc string) []HelpItem {
+	i := len(items) - 1
+	for i >= 0 && items[i].Separator {
+		i--
+	}
 
-	if c.Usage != "" {
-		fmt.Fprintf(b, " %s", c.Usage)
-	} else if c.Args != nil {
-		fmt.Fprintf(b, " [flags_and_args]")
-	} else {
-		fmt.Fprintf(b, " [flags]")
+	if i >= 0 && !items[i].Header {
+		items = append(items, HelpItem{Separator: true})
 	}
 
-	if c.Description != "" {
-		fmt.Fprintf(b, " - %s", c.Description)
+	return append(items, HelpItem{Header: true, Description: desc})
+}
+
+// groupHelpItems splits items by Group.
+// Groups are ordered as declared in order, the rest follow in the order of the first appearance.
+// Separators and headers go to the group of the following item.
+func groupHelpItems(items []HelpItem, order []string) (gs []HelpGroup) {
+	names := GroupOrder(order, len(items), func(i int) (string, bool) {
+		it := items[i]
+		return it.Group, !it.Separator && !it.Header
+	})
+
+	idx := make(map[string]int, len(names))
+
+	for _, n := range names {
+		idx[n] = len(gs)
+		gs = append(gs, HelpGroup{Name: n})
 	}
 
-	fmt.Fprintf(b, "\n")
+	var pending []HelpItem
 
-	if c.Help != "" {
-		fmt.Fprintf(b, "\n%s\n", c.Help)
+	for _, it := range items {
+		if it.Separator || it.Header {
+			pending = append(pending, it)
+			continue
+		}
+
+		g := &gs[idx[it.Group]]
+
+		g.Items = append(g.Items, pending...)
+		g.Items = append(g.Items, it)
+
+		pending = pending[:0]
+	}
+
+	if len(pending) != 0 && len(gs) != 0 {
+		g := &gs[len(gs)-1]
+		g.Items = append(g.Items, pending...)
 	}
 
-	if len(c.Commands) != 0 {
-		cnt := 0
-		namew := minNameW
+	return gs
+}
 
-		for _, sub := range c.Commands {
-			if sub == nil {
-				// spacing
-			} else if sub.Hidden && !hidden {
-				continue
-			} else if w := len(sub.Name) + len(sub.Usage); w > namew {
-				namew = w
-			}
+// GroupOrder returns used group names in the order of display.
+// Groups listed in order come first, the rest follow in the order of the first appearance.
+// group returns i-th item group and whether the item is to be counted.
+// Empty name stands for ungrouped items.
+func GroupOrder(order []string, n int, group func(i int) (string, bool)) (names []string) {
+	used := map[string]bool{}
+
+	for i := 0; i < n; i++ {
+		if g, ok := group(i); ok {
+			used[g] = true
+		}
+	}
 
-			cnt++
+	for _, g := range order {
+		if used[g] {
+			names = append(names, g)
+			delete(used, g)
 		}
+	}
 
-		if cnt != 0 {
-			fmt.Fprintf(b, "\nSubcommands\n")
+	for i := 0; i < n; i++ {
+		if g, ok := group(i); ok && used[g] {
+			names = append(names, g)
+			delete(used, g)
 		}
+	}
 
-		headernl := false
+	return names
+}
 
-		if namew > maxNameW {
-			namew = maxNameW
+// groupedCommands returns c.Commands ordered by groups the same way as help does.
+func groupedCommands(c *Command) (res []*Command) {
+	cmds := c.Commands
+	names := GroupOrder(c.Groups, len(cmds), func(i int) (string, bool) {
+		if cmds[i] == nil {
+			return "", false
 		}
 
-		for _, sub := range c.Commands {
-			switch {
-			case sub == nil:
-				fmt.Fprintf(b, "\n")
-				continue
-			case sub.Name == "":
-				if headernl {
-					fmt.Fprintf(b, "\n")
-					headernl = false
-				}
-
-				fmt.Fprintf(b, "    %*s # %s\n", namew, "", sub.Description)
-				continue
-			case sub.Hidden && !hidden:
-				continue
+		return cmds[i].Group, cmds[i].Name != ""
+	})
+
+	for _, g := range names {
+		for _, sub := range cmds {
+			if sub != nil && sub.Name != "" && sub.Group == g {
+				res = append(res, sub)
 			}
+		}
+	}
 
-			headernl = true
+	return res
+}
 
-			pline(sub.Name, "", sub.Description, namew, nil)
+// groupedFlags returns c.Flags ordered by groups the same way as help does.
+func groupedFlags(c *Command) (res []*Flag) {
+	flags := c.Flags
+	names := GroupOrder(c.Groups, len(flags), func(i int) (string, bool) {
+		if flags[i] == nil {
+			return "", false
 		}
-	}
 
-	for cc := c; cc != nil; cc = cc.Parent {
-		cnt := 0
-		namew := minNameW
+		return flags[i].Group, flags[i].Name != ""
+	})
 
-		for _, f := range cc.Flags {
-			if f == nil {
-				// spacing
-			} else if f.Hidden && !hidden || cc != c && f.Local {
-				continue
-			} else if w := len(f.Name) + len(f.Usage); w > namew {
-				namew = w
+	for _, g := range names {
+		for _, f := range flags {
+			if f != nil && f.Name != "" && f.Group == g {
+				res = append(res, f)
 			}
+		}
+	}
 
-			cnt++
+	return res
+}
+
+func hasHelpItems(items []HelpItem) bool {
+	for _, it := range items {
+		if !it.Separator && !it.Header {
+			return true
 		}
+	}
 
-		if cnt == 0 {
-			continue
+	return false
+}
+
+func helpNameWidth(items []HelpItem) int {
+	const minNameW, maxNameW = 20, 40
+
+	w := minNameW
+
+	for _, it := range items {
+		if l := term.StringWidth(it.Name + it.Usage); l > w {
+			w = l
 		}
+	}
 
-		if cc == c {
-			fmt.Fprintf(b, "\nFlags\n")
+	if w > maxNameW {
+		w = maxNameW
+	}
+
+	return w
+}
+
+func (d *HelpData) line(w int, it HelpItem) string {
+	const minDescW = 20
+
+	var b strings.Builder
+
+	switch {
+	case it.Separator:
+		return "\n"
+	case it.Header:
+		fmt.Fprintf(&b, "    %*s # %s\n", w, "", it.Description)
+		return b.String()
+	}
+
+	name := it.Name + it.Usage
+
+	fmt.Fprintf(&b, "    %s", d.color(HelpColorName, name))
+
+	if nw := term.StringWidth(name); nw > w {
+		fmt.Fprintf(&b, "\n    %-*s", w, "")
+	} else {
+		b.WriteString(strings.Repeat(" ", w-nw))
+	}
+
+	descw := 0
+	if d.Width != 0 {
+		descw = d.Width - 4 - w - 3
+
+		if descw < minDescW {
+			descw = minDescW
+		}
+	}
+
+	lines := wrapLines(strings.Split(it.Description, "\n"), descw)
+
+	for i, l := range lines {
+		if i == 0 {
+			fmt.Fprintf(&b, " - ")
 		} else {
-			fmt.Fprintf(b, "\nFlags of parent command %v\n", cc.MainName())
+			fmt.Fprintf(&b, "\n    %-*s   ", w, "")
 		}
 
-		headernl := false
+		fmt.Fprintf(&b, "%s", l)
+	}
+
+	var notes []string
+
+	if it.Required {
+		notes = append(notes, "required")
+	}
+
+	if it.Default != "" {
+		notes = append(notes, "default "+it.Default)
+	}
 
-		if namew > maxNameW {
-			namew = maxNameW
+	if len(notes) != 0 {
+		last := lines[len(lines)-1]
+		note := strings.Join(notes, ", ")
+
+		switch {
+		case last == "":
+		case descw != 0 && term.StringWidth(last)+len(" (")+term.StringWidth(note)+len(")") > descw:
+			note = "(" + note + ")"
+			fmt.Fprintf(&b, "\n    %-*s   ", w, "")
+		default:
+			note = "(" + note + ")"
+			b.WriteString(" ")
 		}
 
-		for _, f := range cc.Flags {
-			switch {
-			case f == nil:
-				fmt.Fprintf(b, "\n")
-				continue
-			case f.Name == "":
-				if headernl {
-					fmt.Fprintf(b, "\n")
-					headernl = false
-				}
+		b.WriteString(d.color(HelpColorDefault, note))
+	}
 
-				fmt.Fprintf(b, "    %*s # %s\n", namew, "", f.Description)
-				continue
-			case f.Hidden && !hidden || cc != c && f.Local:
-				continue
+	fmt.Fprintf(&b, "\n")
+
+	return b.String()
+}
+
+func (d *HelpData) heading(s string) string {
+	return d.color(HelpColorHeading, s)
+}
+
+func (d *HelpData) wrap(s string) string {
+	if d.Width == 0 {
+		return s
+	}
+
+	return strings.Join(wrapLines(strings.Split(s, "\n"), d.Width), "\n")
+}
+
+func (d *HelpData) color(c, s string) string {
+	if !d.Color || s == "" {
+		return s
+	}
+
+	return c + s + HelpColorReset
+}
+
+// helpTerminal returns the width to wrap text to and whether to use colors.
+// Both are off if Stdout is not a terminal.
+// Terminal width is taken from COLUMNS env var or from the terminal itself.
+// Colors can be disabled by NO_COLOR env var.
+// FORCE_COLOR is an explicit opt-in: it turns colors on even if Stdout is not a terminal
+// and takes precedence over NO_COLOR. Text is not wrapped in that case.
+func helpTerminal(c *Command) (width int, color bool) {
+	tty := term.IsTerminalFile(c.Stdout)
+
+	if tty {
+		width = 80
+
+		if w, _, err := term.SizeFile(c.Stdout); err == nil && w > 0 {
+			width = w
+		}
+
+		if w, err := strconv.Atoi(c.Getenv("COLUMNS")); err == nil && w > 0 {
+			width = w
+		}
+	}
+
+	color = tty
+
+	if v := c.Getenv("NO_COLOR"); v != "" {
+		color = false
+	}
+
+	if v := c.Getenv("FORCE_COLOR"); v != "" && v != "0" && v != "false" {
+		color = true
+	}
+
+	return width, color
+}
+
+// wrapLines splits lines longer than width on spaces.
+// Continuation lines keep the original line indentation.
+func wrapLines(lines []string, width int) (res []string) {
+	if width <= 0 {
+		return lines
+	}
+
+	for _, l := range lines {
+		if term.StringWidth(l) <= width {
+			res = append(res, l)
+			continue
+		}
+
+		ind := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+		indw := term.StringWidth(ind)
+		cur, curw := ind, indw
+
+		for _, word := range strings.Fields(l) {
+			ww := term.StringWidth(word)
+
+			if curw > indw && curw+1+ww > width {
+				res = append(res, cur)
+				cur, curw = ind, indw
 			}
 
-			headernl = true
+			if curw > indw {
+				cur += " "
+				curw++
+			}
 
-			pline(f.Name, f.Usage, f.Description, namew, f.Value)
+			cur += word
+			curw += ww
 		}
+
+		res = append(res, cur)
 	}
 
-	_, err = b.WriteTo(c.Stdout)
-	if err != nil {
-		return nil, wrap(err, "write")
+	return res
+}
+
+func helpPad(w int, s string) string {
+	if l := term.StringWidth(s); l < w {
+		return s + strings.Repeat(" ", w-l)
 	}
 
-	return nil, ErrExit
+	return s
+}
+
+func helpIndent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func (c *Command) helpRenderer() HelpRenderer {
+	for q := c; q != nil; q = q.Parent {
+		if q.HelpRenderer != nil {
+			return q.HelpRenderer
+		}
+	}
+
+	return DefaultHelpRenderer
 }
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+
+	"nikand.dev/go/cli/flag"
+)
+
+type (
+	// HelpTopic is a help page not attached to a command.
+	// Topics are registered in Command.Topics and shown by HelpCmd.
+	HelpTopic struct {
+		Name        string // comma separated list of aliases
+		Description string
+		Help        string
+
+		Hidden bool
+	}
+)
+
+// HelpCmd prints help for the command path given in args,
+// for example: app help deploy rollback.
+// The last arg can also be a help topic registered on the command or its parents.
+var HelpCmd = &Command{
+	Name:         "help",
+	Usage:        "[flags] [command...] [topic]",
+	Description:  "print help for a command or a topic",
+	Args:         Args{},
+	Action:       helpAction,
+	Flags:        helpCmdFlags(),
+	builtinFlags: helpCmdFlags,
+}
+
+func helpCmdFlags() []*Flag {
+	return []*Flag{
+		flag.New("all,a", false, "print help for the whole subtree in one page"),
+		flag.New("hidden", false, "show hidden commands, flags and topics"),
+		flag.New("search,s", "", "search commands and flags in the subtree"),
+	}
+}
+
+func helpAction(c *Command) (err error) {
+	cur := c.Parent
+	if cur == nil {
+		cur = c
+	}
+
+	hidden := c.Bool("hidden")
+
+	for i, arg := range c.Args {
+		cur.setup()
+
+		if sub := cur.Command(arg); sub != nil {
+			cur = sub
+
+			continue
+		}
+
+		if t := cur.Topic(arg); t != nil && i == len(c.Args)-1 {
+			return printTopic(c, t)
+		}
+
+		return fmt.Errorf("%w or topic: %v", ErrNoSuchCommand, arg)
+	}
+
+	cur.setup()
+
+	if q := c.String("search"); q != "" {
+		return printSearch(c, cur, q, hidden)
+	}
+
+	if !c.Bool("all") {
+		return PrintHelp(cur, hidden)
+	}
+
+	return printHelpTree(c, cur, hidden)
+}
+
+// Topic finds the help topic registered on the command or its parents.
+func (c *Command) Topic(name string) *HelpTopic {
+	for q := c; q != nil; q = q.Parent {
+		for _, t := range q.Topics {
+			if match(t.Name, name) {
+				return t
+			}
+		}
+	}
+
+	return nil
+}
+
+func printTopic(c *Command, t *HelpTopic) error {
+	d := &HelpData{}
+	d.Width, d.Color = helpTerminal(c)
+
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "%s", d.heading(MainName(t.Name)))
+
+	if t.Description != "" {
+		fmt.Fprintf(&b, " - %s", t.Description)
+	}
+
+	fmt.Fprintf(&b, "\n")
+
+	if t.Help != "" {
+		fmt.Fprintf(&b, "\n%s\n", d.wrap(t.Help))
+	}
+
+	_, err := b.WriteTo(c.Stdout)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
+
+// printHelpTree prints help for the command and all its subcommands.
+// Each command is shown with its full name and its own flags only.
+func printHelpTree(c, root *Command, hidden bool) error {
+	var b bytes.Buffer
+
+	var walk func(cur *Command) error
+	walk = func(cur *Command) error {
+		cur.setup()
+
+		d := NewHelpData(cur, hidden)
+		d.Name = d.Path
+
+		if len(d.Flags) != 0 && d.Flags[0].Parent {
+			d.Flags = nil
+		} else if len(d.Flags) != 0 {
+			d.Flags = d.Flags[:1]
+		}
+
+		if b.Len() != 0 {
+			b.WriteString("\n")
+		}
+
+		err := cur.helpRenderer().RenderHelp(&b, d)
+		if err != nil {
+			return wrap(err, "render %v", d.Path)
+		}
+
+		for _, sub := range cur.Commands {
+			if sub == nil || sub.Name == "" || sub.Hidden && !hidden {
+				continue
+			}
+
+			err = walk(sub)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := walk(root)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.WriteTo(c.Stdout)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
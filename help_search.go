@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// SearchHelp finds commands and flags in the c subtree
+// with the query in their names, aliases, descriptions or help text.
+// Matching is case insensitive.
+// Item names are full command paths, followed by the flag name for flags.
+// Hidden items are included if hidden is true.
+func SearchHelp(c *Command, query string, hidden bool) (items []HelpItem) {
+	query = strings.ToLower(query)
+
+	var walk func(cur *Command)
+	walk = func(cur *Command) {
+		cur.setup()
+
+		path := strings.Join(FullName(cur), " ")
+
+		if searchMatch(query, cur.Name, cur.Description, cur.Help) {
+			items = append(items, HelpItem{
+				Name:        path,
+				Description: cur.Description,
+				Command:     cur,
+			})
+		}
+
+		for _, f := range cur.Flags {
+			if f == nil || f.Name == "" || f.Hidden && !hidden {
+				continue
+			}
+
+			if !searchMatch(query, f.Name, f.Description, f.Help) {
+				continue
+			}
+
+			items = append(items, HelpItem{
+				Name:        path + " --" + f.MainName(),
+				Usage:       f.Placeholder(),
+				Description: f.Description,
+				Required:    f.Required,
+				Flag:        f,
+			})
+		}
+
+		for _, sub := range cur.Commands {
+			if sub == nil || sub.Name == "" || sub.Hidden && !hidden {
+				continue
+			}
+
+			walk(sub)
+		}
+	}
+
+	walk(c)
+
+	return items
+}
+
+func searchMatch(query string, texts ...string) bool {
+	for _, t := range texts {
+		if strings.Contains(strings.ToLower(t), query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printSearch prints SearchHelp results to c.Stdout.
+func printSearch(c, root *Command, query string, hidden bool) error {
+	items := SearchHelp(root, query, hidden)
+
+	d := &HelpData{}
+	d.Width, d.Color = helpTerminal(c)
+
+	var b bytes.Buffer
+
+	if len(items) == 0 {
+		fmt.Fprintf(&b, "nothing found for %q\n", query)
+	}
+
+	w := helpNameWidth(items)
+
+	for _, it := range items {
+		b.WriteString(d.line(w, it))
+	}
+
+	_, err := b.WriteTo(c.Stdout)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
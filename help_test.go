@@ -0,0 +1,493 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/nikandfor/assert"
+	"nikand.dev/go/cli/flag"
+)
+
+func TestHelpDefault(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := helpTestCommand(&buf)
+
+	err := Run(c, []string{"app", "--help"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `app,a [flags] - test app
+
+Long help
+  text.
+
+Subcommands
+    sub,s                - subcommand
+
+
+                         # commands header
+    other                - other command
+
+Flags
+    verbose,v                                - verbose output (default false)
+    config=string                            - config file
+                                               second line (default app.yaml)
+
+
+                                             # header
+    level=int                                - level (default 3)
+    a-very-long-flag-name-which-is-really-long=string
+                                             - long
+    empty-desc=string                        - multi
+                                               default x
+    help,h=[hidden][,json|search:text]       - print command help end exit
+`, buf.String())
+}
+
+func TestHelpTemplate(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := helpTestCommand(&buf)
+	c.HelpRenderer = MustTemplateHelp(`{{ .Path }}: {{ .Description }}
+{{ range .Commands }}{{ if .Command }}* {{ .Command.MainName }}
+{{ end }}{{ end }}
+{{- range .Flags }}{{ .Name }}:{{ range .Flags }}{{ with .Flag }} --{{ .MainName }}{{ end }}{{ end }}
+{{ end }}`)
+
+	err := Run(c, []string{"app", "sub", "--help"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `app sub: subcommand
+sub: --force
+app: --verbose --config --a-very-long-flag-name-which-is-really-long --empty-desc --help
+`, buf.String())
+
+	buf.Reset()
+
+	err = Run(c, []string{"app"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `app: test app
+* sub
+* other
+app: --verbose --config --level --a-very-long-flag-name-which-is-really-long --empty-desc --help
+`, buf.String())
+}
+
+func helpTestCommand(buf *bytes.Buffer) *Command {
+	return &Command{
+		Name:        "app,a",
+		Description: "test app",
+		Help:        "Long help\n  text.",
+		Stdout:      buf,
+		Flags: []*Flag{
+			flag.New("verbose,v", false, "verbose output"),
+			flag.New("config", "app.yaml", "config file\nsecond line"),
+			nil,
+			{Description: "header"},
+			flag.New("level", 3, "level", flag.Local),
+			flag.New("hidden-flag", "", "hidden", flag.Hidden),
+			flag.New("a-very-long-flag-name-which-is-really-long", "", "long"),
+			flag.New("empty-desc", "x", "multi\n"),
+			HelpFlag,
+		},
+		Commands: []*Command{{
+			Name:        "sub,s",
+			Description: "subcommand",
+			Usage:       "[flags] <file>",
+			Args:        Args{},
+			Action:      func(c *Command) error { return nil },
+			Flags: []*Flag{
+				flag.New("force,f", false, "force it"),
+			},
+		}, nil, {
+			Description: "commands header",
+		}, {
+			Name:        "other",
+			Description: "other command",
+			Action:      func(c *Command) error { return nil },
+		}, {
+			Name:   "hidden",
+			Hidden: true,
+			Action: func(c *Command) error { return nil },
+		}},
+	}
+}
+
+func TestHelpTerminal(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name:        "app",
+		Description: "test app",
+		Help:        "Long help text which is definitely longer than the terminal width.",
+		Flags: []*Flag{
+			flag.New("имя,и", "мир", "кого приветствовать"),
+			flag.New("名前", "", "name in japanese"),
+			flag.New("long", 5, "description which needs to be wrapped to fit the terminal"),
+		},
+		Stdout: &buf,
+	}
+
+	err := Run(c, []string{"app"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `app [flags] - test app
+
+Long help text which is definitely longer than the terminal width.
+
+Flags
+    имя,и=string         - кого приветствовать (default мир)
+    名前=string          - name in japanese
+    long=int             - description which needs to be wrapped to fit the terminal (default 5)
+`, buf.String())
+
+	buf.Reset()
+
+	err = Run(c, []string{"app"}, []string{"NO_COLOR=1", "FORCE_COLOR=1"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app [flags] - test app\n\n"+
+		"Long help text which is definitely longer than the terminal width.\n\n"+
+		"\x1b[1mFlags\x1b[0m\n"+
+		"    \x1b[36mимя,и=string\x1b[0m         - кого приветствовать \x1b[2m(default мир)\x1b[0m\n"+
+		"    \x1b[36m名前=string\x1b[0m          - name in japanese\n"+
+		"    \x1b[36mlong=int\x1b[0m             - description which needs to be wrapped to fit the terminal \x1b[2m(default 5)\x1b[0m\n",
+		buf.String(), "FORCE_COLOR turns colors on for a non-terminal")
+
+	d := NewHelpData(c, false)
+	d.Width = 60
+	d.Color = true
+
+	buf.Reset()
+
+	err = DefaultHelpRenderer.RenderHelp(&buf, d)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "app [flags] - test app\n\n"+
+		"Long help text which is definitely longer than the terminal\n"+
+		"width.\n\n"+
+		"\x1b[1mFlags\x1b[0m\n"+
+		"    \x1b[36mимя,и=string\x1b[0m         - кого приветствовать \x1b[2m(default мир)\x1b[0m\n"+
+		"    \x1b[36m名前=string\x1b[0m          - name in japanese\n"+
+		"    \x1b[36mlong=int\x1b[0m             - description which needs to be\n"+
+		"                           wrapped to fit the terminal\n"+
+		"                           \x1b[2m(default 5)\x1b[0m\n", buf.String())
+}
+
+func TestHelpGroups(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name:   "app",
+		Groups: []string{"Management", ""},
+		Flags: []*Flag{
+			{Name: "host", Group: "Network", Description: "host to connect", Action: flag.ParseString},
+			{Name: "verbose", Description: "verbose output", Action: flag.ParseBool},
+			{Name: "port", Group: "Network", Description: "port to connect", Action: flag.ParseInt},
+		},
+		Commands: []*Command{{
+			Name:        "get",
+			Group:       "Basic",
+			Description: "get an object",
+		}, {
+			Name:        "version",
+			Description: "print version",
+		}, {
+			Name:        "create",
+			Group:       "Basic",
+			Description: "create an object",
+		}, {
+			Name:        "drain",
+			Group:       "Management",
+			Description: "drain a node",
+		}},
+		Stdout: &buf,
+	}
+
+	err := Run(c, []string{"app"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `app [flags]
+
+Management
+    drain                - drain a node
+
+Subcommands
+    version              - print version
+
+Basic
+    get                  - get an object
+    create               - create an object
+
+Flags
+    verbose              - verbose output
+
+Network
+    host=string          - host to connect
+    port=int             - port to connect
+`, buf.String())
+
+	buf.Reset()
+
+	err = Run(c, []string{"app", "get"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `get [flags] - get an object
+
+Flags of parent command app
+    verbose              - verbose output
+
+Network (parent command app)
+    host=string          - host to connect
+    port=int             - port to connect
+`, buf.String())
+}
+
+type (
+	testSetter struct{ v string }
+	testTyper  struct{ v int }
+)
+
+func TestHelpPlaceholders(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name: "app",
+		Flags: []*Flag{
+			flag.New("port", 80, "port to listen", flag.Required),
+			flag.New("timeout", time.Second, "request timeout"),
+			flag.New("tag", []string{"a", "b"}, "tags to add"),
+			flag.New("level", &testSetter{v: "info"}, "log level"),
+			flag.New("mode", &testTyper{v: 3}, "mode", flag.DefaultText("three")),
+			flag.New("dry-run", false, "do nothing"),
+			{Name: "ratio", Description: "ratio", Action: flag.ParseFloat64},
+			{Name: "name", Usage: "=NAME", Description: "name", Action: flag.ParseString},
+			FlagfileFlag,
+		},
+		Stdout: &buf,
+	}
+
+	err := PrintHelp(c, false)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `app [flags]
+
+Flags
+    port=int             - port to listen (required, default 80)
+    timeout=duration     - request timeout (default 1s)
+    tag=string...        - tags to add (default a,b)
+    level=value          - log level (default info)
+    mode=mode            - mode (default three)
+    dry-run              - do nothing (default false)
+    ratio=float          - ratio
+    name=NAME            - name
+    flagfile,ff=file     - load flags from file
+`, buf.String())
+}
+
+func (s *testSetter) Set(v string) error { s.v = v; return nil }
+func (s *testSetter) String() string     { return s.v }
+
+func (s *testTyper) Set(v string) error { return nil }
+func (s *testTyper) Type() string       { return "mode" }
+
+func TestHelpCmd(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name:        "app",
+		Description: "test app",
+		Flags: []*Flag{
+			flag.New("verbose,v", false, "verbose output"),
+		},
+		Commands: []*Command{{
+			Name:        "deploy",
+			Description: "deploy the app",
+			Commands: []*Command{{
+				Name:        "rollback",
+				Description: "rollback the last deploy",
+				Action:      func(c *Command) error { return nil },
+				Flags: []*Flag{
+					flag.New("to", "", "version to rollback to"),
+				},
+			}, {
+				Name:   "secret",
+				Hidden: true,
+				Action: func(c *Command) error { return nil },
+			}},
+		}, HelpCmd},
+		Topics: []*HelpTopic{{
+			Name:        "environment,env",
+			Description: "env vars used",
+			Help:        "APP_TOKEN is used for authentication.",
+		}},
+		Stdout: &buf,
+	}
+
+	run := func(args ...string) error {
+		buf.Reset()
+
+		return Run(c, append([]string{"app", "help"}, args...), nil)
+	}
+
+	err := run()
+	assert.NoError(t, err)
+	assert.Equal(t, `app [flags] - test app
+
+Subcommands
+    deploy               - deploy the app
+    help                 - print help for a command or a topic
+
+Help topics
+    environment,env      - env vars used
+
+Flags
+    verbose,v            - verbose output (default false)
+`, buf.String())
+
+	err = run("deploy", "rollback")
+	assert.NoError(t, err)
+	assert.Equal(t, `rollback [flags] - rollback the last deploy
+
+Flags
+    to=string            - version to rollback to
+
+Flags of parent command app
+    verbose,v            - verbose output (default false)
+`, buf.String())
+
+	err = run("env")
+	assert.NoError(t, err)
+	assert.Equal(t, `environment - env vars used
+
+APP_TOKEN is used for authentication.
+`, buf.String())
+
+	err = run("deploy", "nope")
+	assert.ErrorIs(t, err, ErrNoSuchCommand)
+
+	err = run("--all", "deploy")
+	assert.NoError(t, err)
+	assert.Equal(t, `app deploy [flags] - deploy the app
+
+Subcommands
+    rollback             - rollback the last deploy
+
+app deploy rollback [flags] - rollback the last deploy
+
+Flags
+    to=string            - version to rollback to
+`, buf.String())
+
+	err = run("deploy", "rollback")
+	assert.NoError(t, err)
+	assert.Equal(t, `rollback [flags] - rollback the last deploy
+
+Flags
+    to=string            - version to rollback to
+
+Flags of parent command app
+    verbose,v            - verbose output (default false)
+`, buf.String(), "--all is not kept from the previous run")
+}
+
+func TestHelpSearch(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name: "app",
+		Flags: []*Flag{
+			flag.New("timeout", time.Second, "request timeout"),
+			flag.New("debug-retries", false, "log every retry", flag.Hidden),
+			HelpFlag,
+		},
+		Commands: []*Command{{
+			Name:        "fetch,f",
+			Description: "fetch a resource",
+			Help:        "Failed requests are retried.",
+			Action:      func(c *Command) error { return nil },
+			Flags: []*Flag{
+				flag.New("max-retry,r", 3, "max attempts"),
+				flag.New("output,o", "", "output file", func(f *Flag) {
+					f.Help = "Existing file is truncated."
+				}),
+			},
+		}, {
+			Name:        "retry-queue",
+			Description: "manage the queue",
+			Hidden:      true,
+			Action:      func(c *Command) error { return nil },
+		}, HelpCmd},
+		Stdout: &buf,
+	}
+
+	err := Run(c, []string{"app", "fetch", "--help=search:RETR"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `    app fetch                 - fetch a resource
+    app fetch --max-retry=int - max attempts
+`, buf.String())
+
+	buf.Reset()
+
+	err = Run(c, []string{"app", "--help=hidden,search:retr"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `    app --debug-retries       - log every retry
+    app fetch                 - fetch a resource
+    app fetch --max-retry=int - max attempts
+    app retry-queue           - manage the queue
+`, buf.String())
+
+	buf.Reset()
+
+	err = Run(c, []string{"app", "--help=search:truncated"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `    app fetch --output=string - output file
+`, buf.String(), "flag help is searched")
+
+	buf.Reset()
+
+	err = Run(c, []string{"app", "--help=search:nothing-like-that"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "nothing found for \"nothing-like-that\"\n", buf.String())
+
+	buf.Reset()
+	HelpCmd.Stdout = nil // set to the other test buffer
+
+	err = Run(c, []string{"app", "help", "-s", "file"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `    app fetch --output=string - output file
+`, buf.String())
+}
+
+func TestHelpExamples(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &Command{
+		Name:        "app",
+		Description: "test app",
+		Action:      func(c *Command) error { return nil },
+		Examples: []Example{{
+			Command:     "app --name world",
+			Description: "greet the world",
+			Output:      "hello, world\n",
+		}, {
+			Command: "app",
+		}},
+		Stdout: &buf,
+	}
+
+	err := PrintHelp(c, false)
+	assert.NoError(t, err)
+
+	assert.Equal(t, `app [flags] - test app
+
+Examples
+    # greet the world
+    $ app --name world
+    hello, world
+
+    $ app
+`, buf.String())
+}
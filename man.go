@@ -0,0 +1,417 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"nikand.dev/go/cli/flag"
+)
+
+type (
+	// Man generates roff man pages.
+	Man struct {
+		Section string // man section, 1 if empty
+		Date    string // .TH date
+		Source  string // .TH source, usually the program name and version
+		Manual  string // .TH manual title
+
+		Hidden bool // include hidden commands and flags
+	}
+)
+
+// ManCmd prints the man page for the whole command tree,
+// or writes a page per command to the dir if the flag is set.
+var ManCmd = &Command{
+	Name:         "_man",
+	Description:  "print man page",
+	Hidden:       true,
+	Action:       manAction,
+	Flags:        manCmdFlags(),
+	builtinFlags: manCmdFlags,
+}
+
+func manCmdFlags() []*Flag {
+	return []*Flag{
+		flag.New("dir", "", "write a page per command to the dir"),
+		flag.New("section", "1", "man section"),
+		flag.New("date", "", "page date"),
+		flag.New("source", "", "page source, usually the program name and version"),
+		flag.New("hidden", false, "include hidden commands and flags"),
+	}
+}
+
+func manAction(c *Command) error {
+	root := c
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	m := &Man{
+		Section: c.String("section"),
+		Date:    c.String("date"),
+		Source:  c.String("source"),
+		Hidden:  c.Bool("hidden"),
+	}
+
+	if dir := c.String("dir"); dir != "" {
+		_, err := m.WriteDir(dir, root)
+		return err
+	}
+
+	return m.WriteTree(c.Stdout, root)
+}
+
+// ManName is the command page name: the full command name joined by dashes.
+func ManName(c *Command) string {
+	return strings.Join(FullName(c), "-")
+}
+
+// WritePage writes the command man page.
+// Parent and subcommands are referenced in SEE ALSO.
+func (m *Man) WritePage(w io.Writer, c *Command) error {
+	c.setup()
+
+	d := NewHelpData(c, m.Hidden)
+
+	var b bytes.Buffer
+
+	m.header(&b, c, d)
+	m.commands(&b, d, "COMMANDS")
+	m.options(&b, d, ".SH")
+	m.examples(&b, d, ".SH")
+
+	var see []string
+
+	if c.Parent != nil {
+		see = append(see, ManName(c.Parent))
+	}
+
+	for _, it := range d.Commands {
+		if it.Command != nil {
+			see = append(see, ManName(it.Command))
+		}
+	}
+
+	if len(see) != 0 {
+		fmt.Fprintf(&b, ".SH SEE ALSO\n")
+
+		for i, n := range see {
+			sep := ","
+			if i == len(see)-1 {
+				sep = ""
+			}
+
+			fmt.Fprintf(&b, ".BR %s (%s)%s\n", roffEscape(n), m.section(), sep)
+		}
+	}
+
+	return m.write(w, &b)
+}
+
+// WriteTree writes a single page for the command and all its subcommands.
+func (m *Man) WriteTree(w io.Writer, c *Command) error {
+	c.setup()
+
+	d := NewHelpData(c, m.Hidden)
+
+	var b bytes.Buffer
+
+	m.header(&b, c, d)
+	m.options(&b, d, ".SH")
+	m.examples(&b, d, ".SH")
+
+	var walk func(items []HelpItem)
+	walk = func(items []HelpItem) {
+		for _, it := range items {
+			sub := it.Command
+			if sub == nil {
+				continue
+			}
+
+			sub.setup()
+			sd := NewHelpData(sub, m.Hidden)
+
+			fmt.Fprintf(&b, ".SS %s\n", roffQuote(sd.Path))
+
+			if sd.Description != "" {
+				fmt.Fprintf(&b, "%s\n", roffText(sd.Description))
+			}
+
+			fmt.Fprintf(&b, ".PP\n")
+			m.synopsis(&b, sd)
+
+			if sd.Help != "" {
+				fmt.Fprintf(&b, ".PP\n%s\n", roffText(sd.Help))
+			}
+
+			m.options(&b, sd, "")
+			m.examples(&b, sd, "")
+
+			for _, gr := range sd.CommandGroups {
+				walk(gr.Items)
+			}
+		}
+	}
+
+	for _, gr := range d.CommandGroups {
+		fmt.Fprintf(&b, ".SH %s\n", roffQuote(strings.ToUpper(docsGroupName(gr.Name, "Commands"))))
+
+		walk(gr.Items)
+	}
+
+	return m.write(w, &b)
+}
+
+// WriteDir writes a page per command in the subtree to the dir.
+// Files are named ManName.Section. Written file paths are returned.
+func (m *Man) WriteDir(dir string, c *Command) (files []string, err error) {
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, wrap(err, "mkdir")
+	}
+
+	var walk func(cur *Command) error
+	walk = func(cur *Command) error {
+		var b bytes.Buffer
+
+		err := m.WritePage(&b, cur)
+		if err != nil {
+			return wrap(err, "%v", ManName(cur))
+		}
+
+		name := filepath.Join(dir, ManName(cur)+"."+m.section())
+
+		err = os.WriteFile(name, b.Bytes(), 0o644)
+		if err != nil {
+			return wrap(err, "write file")
+		}
+
+		files = append(files, name)
+
+		for _, sub := range cur.Commands {
+			if sub == nil || sub.Name == "" || sub.Hidden && !m.Hidden {
+				continue
+			}
+
+			err = walk(sub)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err = walk(c)
+
+	return files, err
+}
+
+func (m *Man) header(b *bytes.Buffer, c *Command, d *HelpData) {
+	fmt.Fprintf(b, ".TH %s %s %s %s %s\n", roffQuote(strings.ToUpper(ManName(c))), roffQuote(m.section()),
+		roffQuote(m.Date), roffQuote(m.Source), roffQuote(m.Manual))
+
+	fmt.Fprintf(b, ".SH NAME\n%s", roffEscape(ManName(c)))
+
+	if d.Description != "" {
+		fmt.Fprintf(b, " \\- %s", roffEscape(strings.ReplaceAll(d.Description, "\n", " ")))
+	}
+
+	fmt.Fprintf(b, "\n.SH SYNOPSIS\n")
+	m.synopsis(b, d)
+
+	if d.Help != "" {
+		fmt.Fprintf(b, ".SH DESCRIPTION\n%s\n", roffText(d.Help))
+	}
+}
+
+func (m *Man) synopsis(b *bytes.Buffer, d *HelpData) {
+	fmt.Fprintf(b, ".B %s\n%s\n", roffQuote(d.Path), roffText(d.Usage))
+}
+
+func (m *Man) commands(b *bytes.Buffer, d *HelpData, title string) {
+	for _, gr := range d.CommandGroups {
+		fmt.Fprintf(b, ".SH %s\n", roffQuote(strings.ToUpper(docsGroupName(gr.Name, title))))
+
+		for _, it := range gr.Items {
+			if it.Command == nil {
+				continue
+			}
+
+			fmt.Fprintf(b, ".TP\n.B %s\n%s\n", roffQuote(it.Name), roffText(it.Description))
+		}
+	}
+}
+
+// options writes the command flags and the inherited parent flags.
+// Sections are .SH if sect is set, or paragraphs otherwise.
+func (m *Man) options(b *bytes.Buffer, d *HelpData, sect string) {
+	for _, fs := range d.Flags {
+		for _, gr := range fs.Groups {
+			title := docsGroupName(gr.Name, "Options")
+
+			switch {
+			case fs.Parent && gr.Name != "":
+				title += " (inherited from " + fs.Name + ")"
+			case fs.Parent:
+				title = "Options inherited from " + fs.Name
+			}
+
+			if sect == "" {
+				fmt.Fprintf(b, ".PP\n%s\n", roffEscape(title+":"))
+			} else {
+				fmt.Fprintf(b, "%s %s\n", sect, roffQuote(strings.ToUpper(title)))
+			}
+
+			for _, it := range gr.Items {
+				if it.Flag == nil {
+					continue
+				}
+
+				fmt.Fprintf(b, ".TP\n%s\n", manFlagName(it))
+
+				if it.Description != "" {
+					fmt.Fprintf(b, "%s\n", roffText(it.Description))
+				}
+
+				var notes []string
+
+				if it.Required {
+					notes = append(notes, "required")
+				}
+
+				if it.Default != "" {
+					notes = append(notes, "default: "+it.Default)
+				}
+
+				if env := FlagEnv(d.Command, it.Flag); env != "" {
+					notes = append(notes, "env: "+env)
+				}
+
+				if len(notes) != 0 {
+					fmt.Fprintf(b, ".br\n%s\n", roffEscape("("+strings.Join(notes, ", ")+")"))
+				}
+			}
+		}
+	}
+}
+
+func (m *Man) examples(b *bytes.Buffer, d *HelpData, sect string) {
+	if len(d.Examples) == 0 {
+		return
+	}
+
+	if sect == "" {
+		fmt.Fprintf(b, ".PP\nExamples:\n")
+	} else {
+		fmt.Fprintf(b, "%s EXAMPLES\n", sect)
+	}
+
+	for _, e := range d.Examples {
+		if e.Description != "" {
+			fmt.Fprintf(b, ".PP\n%s\n", roffText(e.Description))
+		}
+
+		fmt.Fprintf(b, ".PP\n.RS 4\n.nf\n%s\n", roffEscape("$ "+e.Command))
+
+		if e.Output != "" {
+			fmt.Fprintf(b, "%s\n", roffLines(e.Output))
+		}
+
+		fmt.Fprintf(b, ".fi\n.RE\n")
+	}
+}
+
+func (m *Man) section() string {
+	if m.Section == "" {
+		return "1"
+	}
+
+	return m.Section
+}
+
+func (m *Man) write(w io.Writer, b *bytes.Buffer) error {
+	_, err := b.WriteTo(w)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
+
+// manFlagName formats flag aliases and the value placeholder: \fB\-\-to\fR, \fB\-t\fR=\fIstring\fR.
+func manFlagName(it HelpItem) string {
+	var b strings.Builder
+
+	for i, n := range strings.Split(it.Name, ",") {
+		if i != 0 {
+			b.WriteString(", ")
+		}
+
+		dd := "--"
+		if len(n) == 1 {
+			dd = "-"
+		}
+
+		fmt.Fprintf(&b, "\\fB%s\\fR", roffEscape(dd+n))
+	}
+
+	if p := strings.TrimPrefix(it.Usage, "="); p != "" {
+		fmt.Fprintf(&b, "=\\fI%s\\fR", roffEscape(p))
+	}
+
+	return b.String()
+}
+
+// roffText escapes text and replaces empty lines with paragraph breaks.
+func roffText(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			lines[i] = ".PP"
+			continue
+		}
+
+		lines[i] = roffLine(l)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// roffLines escapes text keeping the lines as is. Used in .nf blocks.
+func roffLines(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+
+	for i, l := range lines {
+		lines[i] = roffLine(l)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// roffLine escapes text line so it's not taken for a request.
+func roffLine(s string) string {
+	s = roffEscape(s)
+
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+
+	return s
+}
+
+func roffQuote(s string) string {
+	return `"` + strings.ReplaceAll(roffEscape(s), `"`, `""`) + `"`
+}
+
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+
+	return s
+}
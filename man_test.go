@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nikandfor/assert"
+	"nikand.dev/go/cli/flag"
+)
+
+func manTestCommand() *Command {
+	return &Command{
+		Name:        "app",
+		Description: "test app",
+		Help:        "App does things.\n\n.dots and -dashes are escaped.",
+		EnvPrefix:   "APP_",
+		Flags: []*Flag{
+			flag.New("verbose,v", false, "verbose output"),
+			flag.New("level", 3, "log level", flag.Local),
+			flag.New("debug", false, "debug", flag.Hidden),
+		},
+		Commands: []*Command{{
+			Name:        "deploy,d",
+			Description: "deploy the app",
+			Usage:       "[flags] <version>",
+			Args:        Args{},
+			Flags: []*Flag{
+				flag.New("to", "prod", "environment", flag.Required),
+			},
+			Examples: []Example{{
+				Command:     "app deploy v1",
+				Description: "deploy v1",
+				Output:      "deployed\n",
+			}},
+			Commands: []*Command{{
+				Name:        "rollback",
+				Description: "rollback",
+			}},
+		}, {
+			Name:   "secret",
+			Hidden: true,
+		}, ManCmd},
+	}
+}
+
+func TestManPage(t *testing.T) {
+	c := manTestCommand()
+	c.setup()
+
+	var b bytes.Buffer
+
+	m := &Man{Date: "Jan 2024", Source: "app 1.0"}
+
+	err := m.WritePage(&b, c.Commands[0])
+	assert.NoError(t, err)
+
+	assert.Equal(t, `.TH "APP\-DEPLOY" "1" "Jan 2024" "app 1.0" ""
+.SH NAME
+app\-deploy \- deploy the app
+.SH SYNOPSIS
+.B "app deploy"
+[flags] <version>
+.SH "COMMANDS"
+.TP
+.B "rollback"
+rollback
+.SH "OPTIONS"
+.TP
+\fB\-\-to\fR=\fIstring\fR
+environment
+.br
+(required, default: prod, env: APP_TO)
+.SH "OPTIONS INHERITED FROM APP"
+.TP
+\fB\-\-verbose\fR, \fB\-v\fR
+verbose output
+.br
+(default: false, env: APP_VERBOSE)
+.SH EXAMPLES
+.PP
+deploy v1
+.PP
+.RS 4
+.nf
+$ app deploy v1
+deployed
+.fi
+.RE
+.SH SEE ALSO
+.BR app (1),
+.BR app\-deploy\-rollback (1)
+`, b.String())
+}
+
+func TestManTree(t *testing.T) {
+	c := manTestCommand()
+
+	var b bytes.Buffer
+
+	c.Stdout = &b
+
+	err := Run(c, []string{"app", "_man"}, nil)
+	assert.NoError(t, err)
+
+	s := b.String()
+
+	assert.True(t, strings.HasPrefix(s, `.TH "APP" "1" "" "" ""
+.SH NAME
+app \- test app
+.SH SYNOPSIS
+.B "app"
+[flags]
+.SH DESCRIPTION
+App does things.
+.PP
+\&.dots and \-dashes are escaped.
+.SH "OPTIONS"
+`), "%s", s)
+
+	assert.True(t, strings.Contains(s, `.SS "app deploy"
+deploy the app
+.PP
+.B "app deploy"
+[flags] <version>
+.PP
+Options:
+`), "%s", s)
+
+	assert.True(t, strings.Contains(s, `.SS "app deploy rollback"`), "%s", s)
+	assert.True(t, strings.Contains(s, `\fB\-\-level\fR=\fIint\fR`), "%s", s)
+	assert.True(t, !strings.Contains(s, "secret") && !strings.Contains(s, "debug"), "%s", s)
+}
+
+func TestManGroups(t *testing.T) {
+	c := groupsTestCommand()
+	c.setup()
+
+	var b bytes.Buffer
+
+	err := (&Man{}).WritePage(&b, c)
+	assert.NoError(t, err)
+
+	s := b.String()
+
+	assert.True(t, strings.Contains(s, `.SH "MANAGEMENT"
+.TP
+.B "drain"
+drain a node
+.SH "COMMANDS"
+.TP
+.B "version"
+print version
+.SH "BASIC"
+.TP
+.B "get"
+get an object
+.TP
+.B "create"
+create an object
+.SH "OPTIONS"
+.TP
+\fB\-\-verbose\fR
+verbose output
+.SH "NETWORK"
+.TP
+\fB\-\-host\fR=\fIstring\fR
+`), "%s", s)
+
+	b.Reset()
+
+	err = (&Man{}).WriteTree(&b, c)
+	assert.NoError(t, err)
+
+	s = b.String()
+
+	assert.True(t, strings.Contains(s, ".SH \"MANAGEMENT\"\n.SS \"app drain\"\n"), "%s", s)
+	assert.True(t, strings.Contains(s, ".SH \"BASIC\"\n.SS \"app get\"\n"), "%s", s)
+	assert.True(t, strings.Contains(s, ".PP\nNetwork (inherited from app):\n"), "%s", s)
+}
+
+func TestManDir(t *testing.T) {
+	c := manTestCommand()
+	dir := t.TempDir()
+
+	files, err := (&Man{Section: "8"}).WriteDir(dir, c)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(dir, "app.8"),
+		filepath.Join(dir, "app-deploy.8"),
+		filepath.Join(dir, "app-deploy-rollback.8"),
+	}, files)
+
+	data, err := os.ReadFile(filepath.Join(dir, "app-deploy-rollback.8"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `.TH "APP\-DEPLOY\-ROLLBACK" "8"`))
+}
+
+func TestManCmdFlagsReset(t *testing.T) {
+	ManCmd.Stdout = nil // set to the other test buffer
+	defer func() { ManCmd.Stdout = nil }()
+
+	var buf bytes.Buffer
+
+	c := manTestCommand()
+	c.Stdout = &buf
+
+	err := Run(c, []string{"app", "_man", "--hidden", "--section=8"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(buf.String(), `.TH "APP" "8"`))
+	assert.True(t, strings.Contains(buf.String(), "secret"))
+
+	buf.Reset()
+
+	err = Run(c, []string{"app", "_man"}, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(buf.String(), `.TH "APP" "1"`))
+	assert.False(t, strings.Contains(buf.String(), "secret"))
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"strconv"
+
+	"nikand.dev/go/cli/flag"
+	"nikand.dev/go/cli/prompt"
+	"nikand.dev/go/cli/term"
+)
+
+var isTerminal = term.IsTerminalFile
+
+// NoInputFlag disables prompting for missing required flags.
+// CI env var being set does the same.
+var NoInputFlag = &Flag{
+	Name:        "no-input",
+	Description: "never prompt for missing values",
+	Action:      flag.ParseBool,
+	Value:       false,
+}
+
+// DefaultPromptFlag asks for the missing required flag value if the command is Interactive.
+// flag.ErrRequired is returned otherwise.
+func DefaultPromptFlag(c *Command, f *Flag) error {
+	if !c.Interactive() {
+		return flag.ErrRequired
+	}
+
+	return AskFlag(c, f)
+}
+
+// AskFlag asks for the flag value using command Stdin and Stdout.
+// Bool flags are asked as confirmation, and secret flags are read without echo.
+// The answer is used literally, value references are not resolved.
+func AskFlag(c *Command, f *Flag) (err error) {
+	p := prompt.New(c.StdinReader(), c.Stdout)
+
+	q := f.MainName()
+	if f.Description != "" {
+		q += " (" + f.Description + ")"
+	}
+
+	var val string
+
+	switch {
+	case f.Secret:
+		val, err = p.Password(q)
+	default:
+		if _, ok := f.Value.(bool); ok {
+			var v bool
+			v, err = p.Confirm(q, false)
+			val = strconv.FormatBool(v)
+		} else {
+			val, err = p.Text(q, "")
+		}
+	}
+	if err != nil {
+		return wrap(err, "prompt")
+	}
+
+	if val == "" {
+		return flag.ErrRequired
+	}
+
+	f.CurrentCommand = c
+
+	_, err = f.Action(f, "--"+f.MainName()+"="+flag.Literal(f, val), nil)
+	if err != nil {
+		if f.Secret {
+			err = flag.RedactError(err, val)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Interactive reports whether the user can be asked for input.
+// It's false if NoInputFlag is set, or CI env var is set, or Stdin is not a terminal.
+func (c *Command) Interactive() bool {
+	if f := c.Flag(NoInputFlag.MainName()); f != nil && f.IsSet && f.Value == true {
+		return false
+	}
+
+	if v, ok := c.LookupEnv("CI"); ok && v != "" && v != "false" && v != "0" {
+		return false
+	}
+
+	return isTerminal(c.StdinReader())
+}
+
+func (c *Command) promptFlag(f *Flag) error {
+	for q := c; q != nil; q = q.Parent {
+		if q.PromptFlag != nil {
+			return q.PromptFlag(c, f)
+		}
+	}
+
+	return DefaultPromptFlag(c, f)
+}
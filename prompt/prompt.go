@@ -0,0 +1,175 @@
+// Package prompt asks a user questions in a terminal.
+//
+// Input is read byte by byte without buffering,
+// so multiple Prompts can be created over the same reader.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"nikand.dev/go/cli/term"
+)
+
+type (
+	Prompt struct {
+		In  io.Reader
+		Out io.Writer
+	}
+)
+
+var (
+	ErrNoInput    = errors.New("no input")
+	ErrNoEchoFail = errors.New("can't disable echo")
+)
+
+func New(in io.Reader, out io.Writer) *Prompt {
+	return &Prompt{
+		In:  in,
+		Out: out,
+	}
+}
+
+// Text asks for a line of text. def is returned if the answer is empty.
+func (p *Prompt) Text(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.Out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.Out, "%s: ", question)
+	}
+
+	l, err := p.readLine()
+	if err != nil {
+		return "", err
+	}
+
+	if l == "" {
+		return def, nil
+	}
+
+	return l, nil
+}
+
+// Password asks for a secret text.
+// Echo is disabled if In is a terminal.
+// If In is not a terminal the answer is read as is.
+func (p *Prompt) Password(question string) (s string, err error) {
+	fmt.Fprintf(p.Out, "%s: ", question)
+
+	if fd, ok := p.In.(term.Fder); ok && term.IsTerminal(fd.Fd()) {
+		restore, err := term.NoEcho(fd.Fd())
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrNoEchoFail, err)
+		}
+
+		defer func() {
+			e := restore()
+			if err == nil && e != nil {
+				err = fmt.Errorf("restore echo: %w", e)
+			}
+
+			fmt.Fprintf(p.Out, "\n") // user's newline wasn't echoed
+		}()
+	}
+
+	return p.readLine()
+}
+
+// Confirm asks yes or no question. def is returned if the answer is empty.
+func (p *Prompt) Confirm(question string, def bool) (bool, error) {
+	opts := "y/N"
+	if def {
+		opts = "Y/n"
+	}
+
+	for {
+		fmt.Fprintf(p.Out, "%s [%s]: ", question, opts)
+
+		l, err := p.readLine()
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(l) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+
+		fmt.Fprintf(p.Out, "Please answer yes or no.\n")
+	}
+}
+
+// Choice asks to choose one of the options.
+// The answer is either the option number starting from 1 or the option itself.
+// The chosen option index is returned. def index is returned if the answer is empty.
+// def < 0 means no default.
+func (p *Prompt) Choice(question string, options []string, def int) (int, error) {
+	for i, o := range options {
+		fmt.Fprintf(p.Out, "  %d) %s\n", i+1, o)
+	}
+
+	for {
+		if def >= 0 && def < len(options) {
+			fmt.Fprintf(p.Out, "%s [%d]: ", question, def+1)
+		} else {
+			fmt.Fprintf(p.Out, "%s: ", question)
+		}
+
+		l, err := p.readLine()
+		if err != nil {
+			return -1, err
+		}
+
+		if l == "" && def >= 0 && def < len(options) {
+			return def, nil
+		}
+
+		if n, err := strconv.Atoi(l); err == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+
+		for i, o := range options {
+			if l == o {
+				return i, nil
+			}
+		}
+
+		fmt.Fprintf(p.Out, "Please choose one of 1-%d.\n", len(options))
+	}
+}
+
+// readLine reads until newline byte by byte, so nothing extra is consumed from In.
+func (p *Prompt) readLine() (string, error) {
+	var b []byte
+	var c [1]byte
+
+	for {
+		n, err := p.In.Read(c[:])
+		if n == 1 && c[0] == '\n' {
+			break
+		}
+
+		if n == 1 {
+			b = append(b, c[0])
+		}
+
+		if errors.Is(err, io.EOF) && len(b) != 0 {
+			break
+		}
+		if errors.Is(err, io.EOF) {
+			return "", ErrNoInput
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return strings.TrimSuffix(string(b), "\r"), nil
+}
@@ -0,0 +1,51 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nikandfor/assert"
+)
+
+func TestPrompt(t *testing.T) {
+	var out bytes.Buffer
+
+	in := strings.NewReader("alice\n\nsecret\r\nmaybe\ny\n\n5\nblue\n")
+
+	p := New(in, &out)
+
+	s, err := p.Text("name", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", s)
+
+	s, err = p.Text("city", "Paris")
+	assert.NoError(t, err)
+	assert.Equal(t, "Paris", s)
+
+	s, err = p.Password("password")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", s)
+
+	ok, err := p.Confirm("sure", false)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = p.Confirm("really", true)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	i, err := p.Choice("color", []string{"red", "green", "blue"}, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, i)
+
+	_, err = p.Text("more", "")
+	assert.ErrorIs(t, err, ErrNoInput)
+
+	assert.Equal(t, `name: city [Paris]: password: sure [y/N]: Please answer yes or no.
+sure [y/N]: really [Y/n]:   1) red
+  2) green
+  3) blue
+color: Please choose one of 1-3.
+color: more: `, out.String())
+}
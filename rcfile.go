@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"nikand.dev/go/cli/flag"
+)
+
+// NoRCFlag disables rc files discovery.
+// It's checked before any args are parsed, so it works at any position.
+var NoRCFlag = &Flag{
+	Name:        "no-rc",
+	Description: "do not load rc files",
+	Action:      flag.ParseBool,
+	Value:       false,
+}
+
+// NoRCEnv is an env var disabling rc files discovery if set to a true value.
+var NoRCEnv = "CLI_NO_RC"
+
+var getwd = os.Getwd
+
+// loadRCFiles reads c.RCFiles found in rcDirs and returns their content as args.
+func (c *Command) loadRCFiles() (args []string, err error) {
+	dirs, err := c.rcDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range dirs {
+		for _, n := range c.RCFiles {
+			p := filepath.Join(d, n)
+
+			data, err := readFile(p)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, wrap(err, "read file")
+			}
+
+			add, err := splitArgs(data, true)
+			if err != nil {
+				return nil, wrap(err, "parse %v", p)
+			}
+
+			args = append(args, add...)
+			c.RCLoaded = append(c.RCLoaded, p)
+		}
+	}
+
+	return args, nil
+}
+
+// rcDirs returns dirs to look rc files in.
+// The order is from the lowest precedence to the highest:
+// XDG_CONFIG_DIRS, XDG_CONFIG_HOME, HOME, and the working dir with its parents from the root down.
+func (c *Command) rcDirs() (dirs []string, err error) {
+	xdg := []string{"/etc/xdg"}
+	if v := c.Getenv("XDG_CONFIG_DIRS"); v != "" {
+		xdg = filepath.SplitList(v)
+	}
+
+	for i := len(xdg) - 1; i >= 0; i-- {
+		dirs = append(dirs, xdg[i])
+	}
+
+	home := c.Getenv("HOME")
+
+	if v := c.Getenv("XDG_CONFIG_HOME"); v != "" {
+		dirs = append(dirs, v)
+	} else if home != "" {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+
+	if home != "" {
+		dirs = append(dirs, home)
+	}
+
+	wd, err := getwd()
+	if err != nil {
+		return nil, wrap(err, "get working dir")
+	}
+
+	var up []string
+
+	for d := filepath.Clean(wd); ; {
+		up = append(up, d)
+
+		p := filepath.Dir(d)
+		if p == d {
+			break
+		}
+
+		d = p
+	}
+
+	for i := len(up) - 1; i >= 0; i-- {
+		dirs = append(dirs, up[i])
+	}
+
+	// keep the last (the most important) occurrence
+
+	seen := make(map[string]struct{}, len(dirs))
+	j := len(dirs)
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		d := filepath.Clean(dirs[i])
+
+		if _, ok := seen[d]; ok {
+			continue
+		}
+
+		seen[d] = struct{}{}
+
+		j--
+		dirs[j] = d
+	}
+
+	return dirs[j:], nil
+}
+
+// noRC checks if rc files discovery is disabled by NoRCEnv, by NoRCFlag set from env, or by NoRCFlag in args.
+func (c *Command) noRC(args []string) (off bool) {
+	if v, ok := c.LookupEnv(NoRCEnv); ok {
+		off = boolValue("=" + v)
+	}
+
+	if f := c.Flag(NoRCFlag.MainName()); f != nil && f.IsSet {
+		off, _ = f.Value.(bool)
+	}
+
+	for _, a := range args {
+		if a == "--" {
+			break
+		}
+
+		if a == "" || a[0] != '-' || !match(NoRCFlag.Name, flagName(a)) {
+			continue
+		}
+
+		off = boolValue(a)
+	}
+
+	return off
+}
+
+func boolValue(arg string) bool {
+	var f Flag
+
+	_, err := flag.ParseBool(&f, arg, nil)
+	if err != nil {
+		return false
+	}
+
+	return f.Value.(bool)
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/nikandfor/assert"
+	"nikand.dev/go/cli/flag"
+)
+
+func TestRCFiles(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+	defer func(old func() (string, error)) { getwd = old }(getwd)
+
+	files := map[string]string{
+		"/etc/xdg/.apprc":        `--a=xdg --b=xdg`,
+		"/home/user/.apprc":      `--b=home --c=home # comment`,
+		"/home/user/proj/.apprc": `--c=proj`,
+	}
+
+	readFile = func(n string) ([]byte, error) {
+		if d, ok := files[n]; ok {
+			return []byte(d), nil
+		}
+
+		return nil, fs.ErrNotExist
+	}
+
+	getwd = func() (string, error) { return "/home/user/proj", nil }
+
+	c := &Command{
+		Name:    "app",
+		Action:  func(c *Command) error { return nil },
+		RCFiles: []string{".apprc"},
+		Flags: []*Flag{
+			flag.New("a", "", ""),
+			flag.New("b", "", ""),
+			flag.New("c", "", ""),
+			flag.New("d", "", ""),
+			NoRCFlag,
+		},
+	}
+
+	err := Run(c, []string{"app", "--d=arg"}, []string{"HOME=/home/user"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "xdg", c.Flag("a").Value)
+	assert.Equal(t, "home", c.Flag("b").Value)
+	assert.Equal(t, "proj", c.Flag("c").Value)
+	assert.Equal(t, "arg", c.Flag("d").Value)
+
+	assert.Equal(t, []string{"/etc/xdg/.apprc", "/home/user/.apprc", "/home/user/proj/.apprc"}, c.RCLoaded)
+
+	err = Run(c, []string{"app", "--c=arg"}, []string{"HOME=/home/user", "XDG_CONFIG_DIRS=/nowhere"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "home", c.Flag("b").Value)
+	assert.Equal(t, "arg", c.Flag("c").Value)
+	assert.Equal(t, []string{"/home/user/.apprc", "/home/user/proj/.apprc"}, c.RCLoaded)
+}
+
+func TestRCFilesDisabled(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+	defer func(old func() (string, error)) { getwd = old }(getwd)
+
+	readFile = func(n string) ([]byte, error) {
+		if n == "/work/.apprc" {
+			return []byte(`--a=rc`), nil
+		}
+
+		return nil, fs.ErrNotExist
+	}
+
+	getwd = func() (string, error) { return "/work", nil }
+
+	for _, tc := range []struct {
+		args []string
+		env  []string
+		off  bool
+	}{
+		{args: []string{"app"}},
+		{args: []string{"app", "--no-rc"}, off: true},
+		{args: []string{"app", "sub", "--no-rc=true"}, off: true},
+		{args: []string{"app", "--no-rc=false"}},
+		{args: []string{"app"}, env: []string{"CLI_NO_RC=1"}, off: true},
+		{args: []string{"app"}, env: []string{"APP_NO_RC=yes"}, off: true},
+		{args: []string{"app", "--no-rc=0"}, env: []string{"APP_NO_RC=1"}},
+	} {
+		c := &Command{
+			Name:      "app",
+			Action:    func(c *Command) error { return nil },
+			EnvPrefix: "APP_",
+			RCFiles:   []string{".apprc"},
+			Commands: []*Command{{
+				Name:   "sub",
+				Action: func(c *Command) error { return nil },
+			}},
+			Flags: []*Flag{
+				flag.New("a", "def", ""),
+				flag.New("no-rc", false, ""),
+			},
+		}
+
+		err := Run(c, tc.args, tc.env)
+		assert.NoError(t, err, "args %q  env %q", tc.args, tc.env)
+
+		exp := "rc"
+		if tc.off {
+			exp = "def"
+		}
+
+		assert.Equal(t, exp, c.Flag("a").Value, "args %q  env %q", tc.args, tc.env)
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+type (
+	respExpander struct {
+		stdin io.Reader
+
+		depth    int
+		dashdash bool
+	}
+)
+
+var (
+	// MaxResponseFilesDepth limits response files nesting.
+	MaxResponseFilesDepth = 16
+
+	ErrResponseFilesDepth = errors.New("response files nesting is too deep")
+)
+
+// expandResponseFiles replaces @file args with the files content.
+// args[0] is not expanded.
+func expandResponseFiles(c *Command, args []string) (_ []string, err error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+
+	e := respExpander{stdin: c.StdinReader()}
+
+	res := []string{args[0]}
+
+	return e.expand(args[1:], res)
+}
+
+func (e *respExpander) expand(args, res []string) (_ []string, err error) {
+	for _, a := range args {
+		if e.dashdash || len(a) < 2 || a[0] != '@' {
+			e.dashdash = e.dashdash || a == "--"
+			res = append(res, a)
+
+			continue
+		}
+
+		if e.depth == MaxResponseFilesDepth {
+			return nil, ErrResponseFilesDepth
+		}
+
+		var data []byte
+
+		if a == "@-" {
+			data, err = io.ReadAll(e.stdin)
+			if err != nil {
+				return nil, wrap(err, "read stdin")
+			}
+		} else {
+			data, err = readFile(a[1:])
+			if err != nil {
+				return nil, wrap(err, "read file")
+			}
+		}
+
+		var add []string
+
+		if bytes.IndexByte(data, 0) != -1 {
+			add = splitNUL(data)
+		} else {
+			add, err = splitArgs(data, false)
+			if err != nil {
+				return nil, wrap(err, "%v", a)
+			}
+		}
+
+		e.depth++
+
+		res, err = e.expand(add, res)
+		if err != nil {
+			return nil, wrap(err, "%v", a)
+		}
+
+		e.depth--
+	}
+
+	return res, nil
+}
+
+// splitNUL splits NUL-terminated or NUL-separated args.
+func splitNUL(data []byte) (args []string) {
+	for len(data) != 0 {
+		p := bytes.IndexByte(data, 0)
+		if p == -1 {
+			p = len(data)
+		}
+
+		args = append(args, string(data[:p]))
+
+		if p == len(data) {
+			break
+		}
+
+		data = data[p+1:]
+	}
+
+	return args
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nikandfor/assert"
+	"nikand.dev/go/cli/flag"
+)
+
+func TestResponseFiles(t *testing.T) {
+	defer func(old func(string) ([]byte, error)) { readFile = old }(readFile)
+
+	readFile = func(n string) ([]byte, error) {
+		switch n {
+		case "args.rsp":
+			return []byte(`--flag "a b" 'c d'
+			@nested.rsp`), nil
+		case "nested.rsp":
+			return []byte(`#not-a-comment`), nil
+		case "loop.rsp":
+			return []byte(`@loop.rsp`), nil
+		}
+
+		assert.Fail(t, "unexpected file", "%v", n)
+
+		return nil, nil
+	}
+
+	c := &Command{
+		Name:          "app",
+		Args:          Args{},
+		Action:        func(c *Command) error { return nil },
+		ResponseFiles: true,
+		Stdin:         strings.NewReader("first\x00second arg\x00"),
+		Flags: []*Flag{
+			flag.New("flag", "", ""),
+		},
+	}
+
+	err := Run(c, []string{"app", "@args.rsp", "x", "@-", "--", "@args.rsp"}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "a b", c.Flag("flag").Value)
+	assert.Equal(t, Args{"c d", "#not-a-comment", "x", "first", "second arg", "@args.rsp"}, c.Args)
+	assert.Equal(t, []string{"app", "--flag", "a b", "c d", "#not-a-comment", "x", "first", "second arg", "--", "@args.rsp"}, c.OSArgs)
+
+	c.Args = Args{}
+
+	err = Run(c, []string{"app", "@loop.rsp"}, nil)
+	assert.ErrorIs(t, err, ErrResponseFilesDepth)
+}
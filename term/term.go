@@ -0,0 +1,34 @@
+// Package term provides the minimal terminal support needed by the library
+// without external dependencies.
+package term
+
+import "errors"
+
+type (
+	// Fder is implemented by *os.File.
+	Fder interface {
+		Fd() uintptr
+	}
+)
+
+var ErrNotSupported = errors.New("terminal is not supported")
+
+// IsTerminalFile reports whether f is a file connected to a terminal.
+func IsTerminalFile(f interface{}) bool {
+	fd, ok := f.(Fder)
+	if !ok {
+		return false
+	}
+
+	return IsTerminal(fd.Fd())
+}
+
+// SizeFile returns terminal width and height if f is a file connected to a terminal.
+func SizeFile(f interface{}) (w, h int, err error) {
+	fd, ok := f.(Fder)
+	if !ok {
+		return 0, 0, ErrNotSupported
+	}
+
+	return Size(fd.Fd())
+}
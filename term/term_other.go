@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package term
+
+// IsTerminal reports whether fd is a terminal.
+func IsTerminal(fd uintptr) bool { return false }
+
+// Size returns terminal width and height.
+func Size(fd uintptr) (w, h int, err error) { return 0, 0, ErrNotSupported }
+
+// NoEcho disables input echo on the terminal.
+// restore must be called to enable it back.
+func NoEcho(fd uintptr) (restore func() error, err error) { return nil, ErrNotSupported }
@@ -0,0 +1,66 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package term
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	Row, Col       uint16
+	Xpixel, Ypixel uint16
+}
+
+// IsTerminal reports whether fd is a terminal.
+func IsTerminal(fd uintptr) bool {
+	var t syscall.Termios
+
+	return ioctl(fd, ioctlGetTermios, unsafe.Pointer(&t)) == nil
+}
+
+// Size returns terminal width and height.
+func Size(fd uintptr) (w, h int, err error) {
+	var ws winsize
+
+	err = ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// NoEcho disables input echo on the terminal.
+// restore must be called to enable it back.
+func NoEcho(fd uintptr) (restore func() error, err error) {
+	var old syscall.Termios
+
+	err = ioctl(fd, ioctlGetTermios, unsafe.Pointer(&old))
+	if err != nil {
+		return nil, err
+	}
+
+	t := old
+	t.Lflag &^= syscall.ECHO
+	t.Lflag |= syscall.ICANON | syscall.ISIG
+
+	err = ioctl(fd, ioctlSetTermios, unsafe.Pointer(&t))
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		return ioctl(fd, ioctlSetTermios, unsafe.Pointer(&old))
+	}, nil
+}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	_, _, e := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg))
+	if e != 0 {
+		return e
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package term
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// wide are East Asian Wide and Fullwidth ranges taking two terminal cells.
+var wide = []struct{ lo, hi rune }{
+	{0x1100, 0x115f},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe30, 0xfe4f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x1f300, 0x1f64f},
+	{0x1f900, 0x1f9ff},
+	{0x20000, 0x2fffd},
+	{0x30000, 0x3fffd},
+}
+
+// StringWidth returns the number of terminal cells s takes.
+// ANSI escape sequences and zero width runes are not counted,
+// wide runes count as two.
+func StringWidth(s string) (w int) {
+	for i := 0; i < len(s); {
+		if s[i] == '\x1b' {
+			i = skipEscape(s, i)
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		w += RuneWidth(r)
+	}
+
+	return w
+}
+
+// RuneWidth returns the number of terminal cells r takes.
+func RuneWidth(r rune) int {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case unicode.IsControl(r):
+		return 0
+	}
+
+	for _, rg := range wide {
+		if r < rg.lo {
+			break
+		}
+
+		if r <= rg.hi {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// skipEscape skips CSI sequence started at i.
+func skipEscape(s string, i int) int {
+	i++ // ESC
+
+	if i == len(s) || s[i] != '[' {
+		return i
+	}
+
+	for i++; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+
+	return i
+}
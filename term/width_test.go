@@ -0,0 +1,23 @@
+package term
+
+import (
+	"testing"
+
+	"github.com/nikandfor/assert"
+)
+
+func TestStringWidth(t *testing.T) {
+	for _, tc := range []struct {
+		s string
+		w int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"привет", 6},
+		{"日本語", 6},
+		{"é", 1},
+		{"\x1b[1;36mname\x1b[0m", 4},
+	} {
+		assert.Equal(t, tc.w, StringWidth(tc.s), "%q", tc.s)
+	}
+}
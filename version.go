@@ -1,42 +1,216 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
 
 	"nikand.dev/go/cli/flag"
 )
 
+type (
+	// VersionInfo describes the program build.
+	VersionInfo struct {
+		Version   string `json:"version,omitempty"`
+		Commit    string `json:"commit,omitempty"`
+		Date      string `json:"date,omitempty"`
+		Dirty     bool   `json:"dirty,omitempty"` // built from a modified working tree
+		Path      string `json:"path,omitempty"`  // main package path
+		Module    string `json:"module,omitempty"`
+		GoVersion string `json:"go_version,omitempty"`
+
+		Deps []VersionDep `json:"deps,omitempty"`
+	}
+
+	VersionDep struct {
+		Path    string      `json:"path"`
+		Version string      `json:"version,omitempty"`
+		Sum     string      `json:"sum,omitempty"`
+		Replace *VersionDep `json:"replace,omitempty"`
+	}
+)
+
+var readBuildInfo = debug.ReadBuildInfo
+
+// Version returns the version command.
+// Empty ver, commit and date are taken from the Go build info, see BuildVersion.
 func Version(ver, commit, date string) *Command {
 	return &Command{
 		Name:        "version",
 		Description: "print version, commit hash and build date",
 		Action: func(c *Command) (err error) {
-			if c.Bool("short") {
-				fmt.Printf("%v\n", ver)
+			v := BuildVersion(ver, commit, date)
 
-				return nil
+			switch {
+			case c.Bool("json"):
+				return v.writeJSON(c.Stdout, c.Bool("verbose"))
+			case c.Bool("short"):
+				_, err = fmt.Fprintf(c.Stdout, "%v\n", v.Version)
+			case c.Bool("commit"):
+				_, err = fmt.Fprintf(c.Stdout, "%v\n", v.Commit)
+			case c.Bool("date"):
+				_, err = fmt.Fprintf(c.Stdout, "%v\n", v.Date)
+			default:
+				err = v.write(c.Stdout, c.Bool("verbose"))
 			}
 
-			if c.Bool("commit") {
-				fmt.Printf("%v\n", commit)
+			return err
+		},
+		Flags: []*Flag{
+			flag.New("short", false, "prints only version tag"),
+			flag.New("commit", false, "prints only commit hash"),
+			flag.New("date", false, "prints only date"),
+			flag.New("json", false, "print json"),
+			flag.New("verbose,v", false, "print go version and dependencies"),
+		},
+	}
+}
+
+// VersionFlag returns the --version flag which prints the version and exits.
+// --version=json prints it in json. See Version for the args.
+func VersionFlag(ver, commit, date string) *Flag {
+	return &Flag{
+		Name:        "version",
+		Usage:       "=[json]",
+		Description: "print version and exit",
+		Action: func(f *Flag, arg string, args []string) (rest []string, err error) {
+			c := f.CurrentCommand.(*Command)
 
-				return nil
+			_, val, rest, err := flag.ParseArg(arg, args, false, true)
+			if err != nil {
+				return
 			}
 
-			if c.Bool("date") {
-				fmt.Printf("%v\n", date)
+			v := BuildVersion(ver, commit, date)
 
-				return nil
+			if val == "json" {
+				err = v.writeJSON(c.Stdout, false)
+			} else {
+				err = v.write(c.Stdout, false)
+			}
+			if err != nil {
+				return nil, err
 			}
 
-			fmt.Printf("%v %v %v\n", ver, commit, date)
-
-			return nil
-		},
-		Flags: []*Flag{
-			flag.New("short", false, "prints only version tag"),
-			flag.New("commit", false, "prints only commit hash"),
-			flag.New("date", false, "prints only date"),
+			return nil, ErrExit
 		},
 	}
 }
+
+// BuildVersion returns the version info.
+// Empty ver, commit and date are taken from runtime/debug.ReadBuildInfo:
+// the main module version and the vcs.revision and vcs.time settings.
+// Dirty is taken from vcs.modified. Dependencies are always added from the build info.
+// Settings are only available since go1.18.
+func BuildVersion(ver, commit, date string) *VersionInfo {
+	v := &VersionInfo{
+		Version: ver,
+		Commit:  commit,
+		Date:    date,
+	}
+
+	bi, ok := readBuildInfo()
+	if !ok {
+		return v
+	}
+
+	v.Path = bi.Path
+	v.Module = bi.Main.Path
+
+	if v.Version == "" {
+		v.Version = bi.Main.Version
+	}
+
+	buildSettings(v, bi)
+
+	for _, d := range bi.Deps {
+		v.Deps = append(v.Deps, versionDep(d))
+	}
+
+	return v
+}
+
+func versionDep(d *debug.Module) VersionDep {
+	r := VersionDep{
+		Path:    d.Path,
+		Version: d.Version,
+		Sum:     d.Sum,
+	}
+
+	if d.Replace != nil {
+		rep := versionDep(d.Replace)
+		r.Replace = &rep
+	}
+
+	return r
+}
+
+// write prints version, commit and date in one line.
+// If verbose the go version, the main package path and dependencies follow it.
+func (v *VersionInfo) write(w io.Writer, verbose bool) error {
+	var b strings.Builder
+
+	for _, s := range []string{v.Version, v.Commit, v.Date} {
+		if s == "" {
+			continue
+		}
+
+		if b.Len() != 0 {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(s)
+	}
+
+	if v.Dirty {
+		b.WriteString(" dirty")
+	}
+
+	b.WriteByte('\n')
+
+	if verbose {
+		if v.GoVersion != "" {
+			fmt.Fprintf(&b, "\tgo\t%v\n", v.GoVersion)
+		}
+
+		if v.Path != "" {
+			fmt.Fprintf(&b, "\tpath\t%v\n", v.Path)
+		}
+
+		for _, d := range v.Deps {
+			fmt.Fprintf(&b, "\tdep\t%v\t%v\t%v\n", d.Path, d.Version, d.Sum)
+
+			if r := d.Replace; r != nil {
+				fmt.Fprintf(&b, "\t=>\t%v\t%v\t%v\n", r.Path, r.Version, r.Sum)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func (v *VersionInfo) writeJSON(w io.Writer, verbose bool) error {
+	if !verbose {
+		cp := *v
+		cp.Deps = nil
+		v = &cp
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return wrap(err, "marshal")
+	}
+
+	data = append(data, '\n')
+
+	_, err = w.Write(data)
+	if err != nil {
+		return wrap(err, "write")
+	}
+
+	return nil
+}
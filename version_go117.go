@@ -0,0 +1,9 @@
+//go:build !go1.18
+// +build !go1.18
+
+package cli
+
+import "runtime/debug"
+
+// buildSettings is a noop as vcs settings are not available before go1.18.
+func buildSettings(v *VersionInfo, bi *debug.BuildInfo) {}
@@ -0,0 +1,26 @@
+//go:build go1.18
+// +build go1.18
+
+package cli
+
+import "runtime/debug"
+
+// buildSettings fills empty commit and date and the dirty flag from the build vcs settings.
+func buildSettings(v *VersionInfo, bi *debug.BuildInfo) {
+	v.GoVersion = bi.GoVersion
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if v.Commit == "" {
+				v.Commit = s.Value
+			}
+		case "vcs.time":
+			if v.Date == "" {
+				v.Date = s.Value
+			}
+		case "vcs.modified":
+			v.Dirty = s.Value == "true"
+		}
+	}
+}
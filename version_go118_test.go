@@ -0,0 +1,42 @@
+//go:build go1.18
+// +build go1.18
+
+package cli
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/nikandfor/assert"
+)
+
+func TestBuildVersionSettings(t *testing.T) {
+	defer func(old func() (*debug.BuildInfo, bool)) { readBuildInfo = old }(readBuildInfo)
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.22.0",
+			Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "0123456789abcdef"},
+				{Key: "vcs.time", Value: "2024-01-02T03:04:05Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+
+	v := BuildVersion("", "", "")
+	assert.Equal(t, &VersionInfo{
+		Version:   "(devel)",
+		Commit:    "0123456789abcdef",
+		Date:      "2024-01-02T03:04:05Z",
+		Dirty:     true,
+		Module:    "example.com/app",
+		GoVersion: "go1.22.0",
+	}, v)
+
+	v = BuildVersion("v1", "abc", "")
+	assert.Equal(t, "v1", v.Version)
+	assert.Equal(t, "abc", v.Commit)
+	assert.Equal(t, "2024-01-02T03:04:05Z", v.Date)
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"bytes"
+	"runtime/debug"
+	"testing"
+
+	"github.com/nikandfor/assert"
+)
+
+func TestVersion(t *testing.T) {
+	defer func(old func() (*debug.BuildInfo, bool)) { readBuildInfo = old }(readBuildInfo)
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Path: "example.com/app/cmd/app",
+			Main: debug.Module{Path: "example.com/app", Version: "v1.2.3"},
+			Deps: []*debug.Module{
+				{Path: "nikand.dev/go/cli", Version: "v0.1.0", Sum: "h1:abc="},
+				{Path: "example.com/lib", Version: "v1.0.0", Replace: &debug.Module{Path: "../lib"}},
+			},
+		}, true
+	}
+
+	var b bytes.Buffer
+
+	app := &Command{
+		Name:     "app",
+		Stdout:   &b,
+		Commands: []*Command{Version("", "abcdef", "2024-01-02")},
+	}
+
+	err := Run(app, []string{"app", "version"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.3 abcdef 2024-01-02\n", b.String())
+
+	b.Reset()
+
+	err = Run(app, []string{"app", "version", "--short"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.3\n", b.String())
+
+	app.Commands[0] = Version("v2", "abcdef", "2024-01-02")
+
+	b.Reset()
+
+	err = Run(app, []string{"app", "version", "-v"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v2 abcdef 2024-01-02\n"+
+		"\tpath\texample.com/app/cmd/app\n"+
+		"\tdep\tnikand.dev/go/cli\tv0.1.0\th1:abc=\n"+
+		"\tdep\texample.com/lib\tv1.0.0\t\n"+
+		"\t=>\t../lib\t\t\n", b.String())
+
+	app.Commands[0] = Version("v2", "abcdef", "2024-01-02")
+
+	b.Reset()
+
+	err = Run(app, []string{"app", "version", "--json"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "version": "v2",
+  "commit": "abcdef",
+  "date": "2024-01-02",
+  "path": "example.com/app/cmd/app",
+  "module": "example.com/app"
+}
+`, b.String())
+}
+
+func TestVersionFlag(t *testing.T) {
+	defer func(old func() (*debug.BuildInfo, bool)) { readBuildInfo = old }(readBuildInfo)
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+
+	var b bytes.Buffer
+
+	app := &Command{
+		Name:   "app",
+		Stdout: &b,
+		Action: func(c *Command) error {
+			t.Errorf("action must not be called")
+			return nil
+		},
+		Flags: []*Flag{VersionFlag("v1.0.0", "", "")},
+	}
+
+	err := Run(app, []string{"app", "--version"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.0.0\n", b.String())
+
+	b.Reset()
+
+	err = Run(app, []string{"app", "--version=json"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"version\": \"v1.0.0\"\n}\n", b.String())
+}